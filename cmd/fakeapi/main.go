@@ -0,0 +1,47 @@
+// Command fakeapi runs the in-memory DutchIS API stand-in so the provider can be
+// exercised locally or in an offline CI runner:
+//
+//	go run ./cmd/fakeapi -listen 127.0.0.1:8080 -token secret -team my-team
+//	export DUTCHIS_API_URL=http://127.0.0.1:8080/api/v1
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/fakeapi"
+)
+
+func main() {
+	listen := flag.String("listen", "127.0.0.1:8080", "address to listen on")
+	token := flag.String("token", "fake-token", "API token to accept")
+	team := flag.String("team", "fake-team", "team UUID to accept")
+	installDuration := flag.Duration("install-duration", 10*time.Second, "how long new servers stay installing")
+	upgradeDuration := flag.Duration("upgrade-duration", 5*time.Second, "how long a resize takes to be applied")
+	deleteDuration := flag.Duration("delete-duration", 5*time.Second, "how long deleted servers keep showing up")
+	powerDuration := flag.Duration("power-duration", 5*time.Second, "how long power actions take")
+	passwordResetDuration := flag.Duration("password-reset-duration", 5*time.Second, "how long a password reset takes")
+	flag.Parse()
+
+	fake := fakeapi.NewServer(*token, *team)
+	fake.InstallDuration = *installDuration
+	fake.UpgradeDuration = *upgradeDuration
+	fake.DeleteDuration = *deleteDuration
+	fake.PowerDuration = *powerDuration
+	fake.PasswordResetDuration = *passwordResetDuration
+
+	server, err := fake.Start(*listen)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer server.Close()
+
+	log.Printf("fake DutchIS API listening, use dutchis_api_url = %q", server.URL+fakeapi.BasePath)
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	<-stop
+}
@@ -0,0 +1,82 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// OSImage is an operating system that virtual servers can be installed with.
+type OSImage struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Family  string `json:"family"`
+	Version string `json:"version"`
+	Windows bool   `json:"windows"`
+}
+
+// Class is a performance class with the resource limits of its virtual servers.
+type Class struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	MinCores   int    `json:"min_cores"`
+	MaxCores   int    `json:"max_cores"`
+	MinMemory  int    `json:"min_memory"`
+	MaxMemory  int    `json:"max_memory"`
+	MinDisk    int    `json:"min_disk"`
+	MaxDisk    int    `json:"max_disk"`
+	MinNetwork int    `json:"min_network"`
+	MaxNetwork int    `json:"max_network"`
+	Available  bool   `json:"available"`
+}
+
+type osImagesResponse struct {
+	Envelope
+	Data []OSImage  `json:"data"`
+	Meta Pagination `json:"meta"`
+}
+
+// ListOSImages returns the operating systems available for new virtual servers.
+func (c *Client) ListOSImages(ctx context.Context) ([]OSImage, error) {
+	var images []OSImage
+	for page := 1; ; page++ {
+		var result osImagesResponse
+		if err := c.do(ctx, http.MethodGet, pagePath("/os", page), nil, &result); err != nil {
+			return nil, err
+		}
+		images = append(images, result.Data...)
+
+		more, err := result.Meta.hasNext("/os", page)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return images, nil
+		}
+	}
+}
+
+type classesResponse struct {
+	Envelope
+	Data []Class    `json:"data"`
+	Meta Pagination `json:"meta"`
+}
+
+// ListClasses returns the performance classes and their resource limits.
+func (c *Client) ListClasses(ctx context.Context) ([]Class, error) {
+	var classes []Class
+	for page := 1; ; page++ {
+		var result classesResponse
+		if err := c.do(ctx, http.MethodGet, pagePath("/classes", page), nil, &result); err != nil {
+			return nil, err
+		}
+		classes = append(classes, result.Data...)
+
+		more, err := result.Meta.hasNext("/classes", page)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return classes, nil
+		}
+	}
+}
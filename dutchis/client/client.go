@@ -0,0 +1,208 @@
+// Package client implements a small typed client for the DutchIS API.
+//
+// All requests share the same authentication headers and the same response
+// envelope handling, so resources only have to deal with typed models.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DefaultBaseURL is the production DutchIS API endpoint.
+const DefaultBaseURL = "https://dutchis.net/api/v1"
+
+// Client talks to the DutchIS API on behalf of a single team.
+type Client struct {
+	BaseURL    string
+	TeamUUID   string
+	APIToken   string
+	HTTPClient *http.Client
+
+	// MaxRetries is the number of times a transient failure is retried.
+	MaxRetries int
+	// MaxRetryWait caps the total time slept between retries of one request.
+	MaxRetryWait time.Duration
+	// Limiter, when set, bounds the number of concurrent requests.
+	Limiter Limiter
+}
+
+// Envelope holds the fields every DutchIS API response carries.
+type Envelope struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// Pagination is the meta object of list responses.
+type Pagination struct {
+	CurrentPage int `json:"current_page"`
+	LastPage    int `json:"last_page"`
+	Total       int `json:"total"`
+}
+
+// MaxPages bounds the number of pages a list call fetches, as a safeguard against an
+// API that keeps reporting more pages.
+const MaxPages = 1000
+
+// hasNext reports whether another page follows the page that was just fetched. The
+// fetched page number is used rather than the current_page the API reports, so an API
+// ignoring the page parameter cannot make the caller loop forever. Unpaginated
+// responses carry no meta object and therefore never have a next page.
+func (p Pagination) hasNext(path string, page int) (bool, error) {
+	if page >= p.LastPage {
+		return false, nil
+	}
+	if page >= MaxPages {
+		return false, fmt.Errorf("DutchIS API GET %s reports %d pages, more than the maximum of %d", path, p.LastPage, MaxPages)
+	}
+	return true, nil
+}
+
+// pagePath adds the page query parameter to path.
+func pagePath(path string, page int) string {
+	return path + "?page=" + strconv.Itoa(page)
+}
+
+// New returns a client for the given team using the default endpoint.
+func New(teamUUID string, apiToken string) *Client {
+	return &Client{
+		BaseURL:      DefaultBaseURL,
+		TeamUUID:     teamUUID,
+		APIToken:     apiToken,
+		HTTPClient:   http.DefaultClient,
+		MaxRetries:   DefaultMaxRetries,
+		MaxRetryWait: DefaultMaxRetryWait,
+	}
+}
+
+// WithTeam returns a copy of the client acting on behalf of another team. The copy
+// shares the HTTP client and the Limiter with the original.
+func (c *Client) WithTeam(teamUUID string) *Client {
+	clone := *c
+	clone.TeamUUID = teamUUID
+	return &clone
+}
+
+// newRequest builds an authenticated request for path with an optional JSON payload.
+func (c *Client) newRequest(ctx context.Context, method string, path string, payload []byte) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.APIToken)
+	if c.TeamUUID != "" {
+		req.Header.Set("X-Team-Uuid", c.TeamUUID)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// do sends a request and decodes the response into out, which is expected to embed Envelope.
+// Error statuses and responses with success set to false are returned as *APIError. When out
+// is nil only the envelope is checked. Transient failures are retried with exponential
+// backoff, see shouldRetry.
+func (c *Client) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var payload []byte
+	if in != nil {
+		var err error
+		payload, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+
+	var waited time.Duration
+	for attempt := 0; ; attempt++ {
+		resp, body, err := c.send(ctx, method, path, payload)
+
+		if attempt < c.MaxRetries && shouldRetry(method, resp, err) {
+			delay := retryDelay(attempt, resp)
+			if waited+delay <= c.MaxRetryWait {
+				waited += delay
+				if err := sleepContext(ctx, delay); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+		return decodeResponse(method, path, resp, body, out)
+	}
+}
+
+// decodeResponse checks the status code and the success flag of the envelope and,
+// if both are fine, decodes body into out.
+func decodeResponse(method string, path string, resp *http.Response, body []byte, out interface{}) error {
+	var envelope Envelope
+	var decodeErr error
+	if len(bytes.TrimSpace(body)) > 0 {
+		decodeErr = json.Unmarshal(body, &envelope)
+	} else {
+		// an empty 2xx response (e.g. 204 No Content) carries no envelope
+		envelope.Success = true
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(method, path, resp, envelope.Message)
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, path, decodeErr)
+	}
+	if !envelope.Success {
+		return newAPIError(method, path, resp, envelope.Message)
+	}
+
+	if out == nil || len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("failed to decode response of %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send performs a single attempt, holding a Limiter slot only while the request is in flight.
+func (c *Client) send(ctx context.Context, method string, path string, payload []byte) (*http.Response, []byte, error) {
+	req, err := c.newRequest(ctx, method, path, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Limiter != nil {
+		release, err := c.Limiter.Acquire(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer release()
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp, nil, err
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPaginationHasNext(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination Pagination
+		page       int
+		want       bool
+		wantErr    bool
+	}{
+		{"unpaginated", Pagination{}, 1, false, false},
+		{"single page", Pagination{CurrentPage: 1, LastPage: 1}, 1, false, false},
+		{"more pages", Pagination{CurrentPage: 1, LastPage: 3}, 1, true, false},
+		{"last page", Pagination{CurrentPage: 3, LastPage: 3}, 3, false, false},
+		{"page parameter ignored", Pagination{CurrentPage: 1, LastPage: 3}, 3, false, false},
+		{"too many pages", Pagination{CurrentPage: MaxPages, LastPage: MaxPages + 1}, MaxPages, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.pagination.hasNext("/sshkeys", test.page)
+			if got != test.want || (err != nil) != test.wantErr {
+				t.Errorf("hasNext(%d) = %t, %v, want %t, error %t", test.page, got, err, test.want, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestListIgnoringPageParameter(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// always the first page, whatever page was asked for
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    []SSHKey{{UUID: "key-1"}},
+			"meta":    Pagination{CurrentPage: 1, LastPage: 3, Total: 3},
+		})
+	}))
+	defer server.Close()
+
+	c := New("team", "token")
+	c.BaseURL = server.URL
+
+	sshkeys, err := c.ListSSHKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 || len(sshkeys) != 3 {
+		t.Errorf("fetched %d pages with %d keys, want 3 pages with 3 keys", requests, len(sshkeys))
+	}
+}
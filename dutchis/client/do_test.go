@@ -0,0 +1,117 @@
+package client_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/dutchis/terraform/dutchis/fakeapi"
+)
+
+const testTeamUUID = "11111111-2222-3333-4444-555555555555"
+
+// newFakeClient returns a client retrying against a fake API, along with the fake API
+// and the number of requests that reached it.
+func newFakeClient(t *testing.T) (*client.Client, *fakeapi.Server, *int32) {
+	t.Helper()
+
+	fake := fakeapi.NewServer("test-token", testTeamUUID)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	c := client.New(testTeamUUID, "test-token")
+	c.BaseURL = server.URL + fakeapi.BasePath
+	c.MaxRetries = 3
+	c.MaxRetryWait = time.Minute
+	return c, fake, &requests
+}
+
+func testCreateSSHKeyRequest() client.CreateSSHKeyRequest {
+	return client.CreateSSHKeyRequest{
+		Name:      "laptop",
+		PublicKey: "ssh-ed25519 " + base64.StdEncoding.EncodeToString([]byte("key data")),
+	}
+}
+
+func TestDoRetriesIdempotentRequests(t *testing.T) {
+	c, fake, requests := newFakeClient(t)
+	fake.FailNext(1, http.StatusBadGateway)
+
+	if _, err := c.ListSSHKeys(context.Background()); err != nil {
+		t.Fatalf("GET was not retried after a 502: %v", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestDoGivesUpAfterMaxRetries(t *testing.T) {
+	c, fake, requests := newFakeClient(t)
+	c.MaxRetries = 1
+	fake.FailNext(3, http.StatusServiceUnavailable)
+
+	_, err := c.ListSSHKeys(context.Background())
+	if !client.IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("error = %v, want the 503", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestDoDoesNotRetryPOST(t *testing.T) {
+	c, fake, requests := newFakeClient(t)
+	fake.FailNext(1, http.StatusServiceUnavailable)
+
+	_, err := c.CreateSSHKey(context.Background(), testCreateSSHKeyRequest())
+	if !client.IsStatus(err, http.StatusServiceUnavailable) {
+		t.Fatalf("error = %v, want the 503", err)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("%d requests, want the POST to be sent once", got)
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	c, fake, requests := newFakeClient(t)
+	// the fake API sends Retry-After: 1 with a 429
+	fake.FailNext(1, http.StatusTooManyRequests)
+
+	start := time.Now()
+	if _, err := c.CreateSSHKey(context.Background(), testCreateSSHKeyRequest()); err != nil {
+		t.Fatalf("POST was not retried after a 429: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the Retry-After of 1s", elapsed)
+	}
+	if got := atomic.LoadInt32(requests); got != 2 {
+		t.Errorf("%d requests, want 2", got)
+	}
+}
+
+func TestDoStopsAtMaxRetryWait(t *testing.T) {
+	c, fake, requests := newFakeClient(t)
+	c.MaxRetryWait = 500 * time.Millisecond
+	fake.FailNext(1, http.StatusTooManyRequests)
+
+	start := time.Now()
+	_, err := c.ListSSHKeys(context.Background())
+	if !client.IsStatus(err, http.StatusTooManyRequests) {
+		t.Fatalf("error = %v, want the 429", err)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("gave up after %s, want no wait beyond MaxRetryWait", elapsed)
+	}
+	if got := atomic.LoadInt32(requests); got != 1 {
+		t.Errorf("%d requests, want 1", got)
+	}
+}
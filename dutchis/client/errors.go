@@ -0,0 +1,60 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError is returned when the DutchIS API answers with an error status or
+// with success set to false.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+	RequestID  string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+
+	msg := fmt.Sprintf("DutchIS API %s %s failed with HTTP %d: %s", e.Method, e.Path, e.StatusCode, message)
+	if e.RequestID != "" {
+		msg += fmt.Sprintf(" (request ID %s)", e.RequestID)
+	}
+	return msg
+}
+
+// requestIDHeaders are the headers the API may use to identify a request.
+var requestIDHeaders = []string{"X-Request-Id", "X-Correlation-Id"}
+
+func newAPIError(method string, path string, resp *http.Response, message string) *APIError {
+	apiErr := &APIError{
+		Method:     method,
+		Path:       path,
+		StatusCode: resp.StatusCode,
+		Message:    message,
+	}
+	for _, header := range requestIDHeaders {
+		if id := resp.Header.Get(header); id != "" {
+			apiErr.RequestID = id
+			break
+		}
+	}
+	return apiErr
+}
+
+// IsStatus reports whether err is an APIError with the given HTTP status code.
+func IsStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
+
+// IsNotFound reports whether err means the requested object does not exist.
+func IsNotFound(err error) bool {
+	return IsStatus(err, http.StatusNotFound)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+		wantAPI bool
+	}{
+		{"success", http.StatusOK, `{"success": true, "data": {"uuid": "abc"}}`, "", false},
+		{"empty no content", http.StatusNoContent, ``, "", false},
+		{"success false", http.StatusOK, `{"success": false, "message": "server locked"}`, "failed with HTTP 200: server locked", true},
+		{"error status", http.StatusConflict, `{"success": false, "message": "virtual server is locked"}`, "failed with HTTP 409: virtual server is locked", true},
+		{"error status without body", http.StatusBadGateway, ``, "failed with HTTP 502: Bad Gateway", true},
+		{"error status with html", http.StatusInternalServerError, `<html>oops</html>`, "failed with HTTP 500: Internal Server Error", true},
+		{"invalid json", http.StatusOK, `{"success": tru`, "failed to decode response of GET /virtualservers/abc", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: test.status, Header: http.Header{}}
+			var out struct {
+				Data struct {
+					UUID string `json:"uuid"`
+				} `json:"data"`
+			}
+
+			err := decodeResponse(http.MethodGet, "/virtualservers/abc", resp, []byte(test.body), &out)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+			}
+			var apiErr *APIError
+			if errors.As(err, &apiErr) != test.wantAPI {
+				t.Errorf("error is an APIError: %t, want %t", !test.wantAPI, test.wantAPI)
+			}
+		})
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		header  string
+		message string
+		want    string
+	}{
+		{"message", http.StatusConflict, "", "virtual server is locked",
+			"DutchIS API DELETE /virtualservers/abc failed with HTTP 409: virtual server is locked"},
+		{"status text fallback", http.StatusNotFound, "", "",
+			"DutchIS API DELETE /virtualservers/abc failed with HTTP 404: Not Found"},
+		{"request id", http.StatusBadRequest, "X-Request-Id", "bad request",
+			"DutchIS API DELETE /virtualservers/abc failed with HTTP 400: bad request (request ID req-1)"},
+		{"correlation id", http.StatusBadRequest, "X-Correlation-Id", "bad request",
+			"DutchIS API DELETE /virtualservers/abc failed with HTTP 400: bad request (request ID req-1)"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: test.status, Header: http.Header{}}
+			if test.header != "" {
+				resp.Header.Set(test.header, "req-1")
+			}
+
+			err := newAPIError(http.MethodDelete, "/virtualservers/abc", resp, test.message)
+			if got := err.Error(); got != test.want {
+				t.Errorf("Error() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsStatus(t *testing.T) {
+	notFound := &APIError{Method: http.MethodGet, Path: "/sshkeys/abc", StatusCode: http.StatusNotFound}
+	wrapped := fmt.Errorf("reading ssh key: %w", notFound)
+
+	if !IsNotFound(notFound) || !IsNotFound(wrapped) {
+		t.Error("IsNotFound does not recognise a (wrapped) 404")
+	}
+	if IsStatus(wrapped, http.StatusConflict) {
+		t.Error("IsStatus matches a different status code")
+	}
+	if IsNotFound(errors.New("not found")) || IsNotFound(nil) {
+		t.Error("IsNotFound matches an error that is not an APIError")
+	}
+}
@@ -0,0 +1,20 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+type permissionsResponse struct {
+	Envelope
+	Permissions []string `json:"permissions"`
+}
+
+// GetPermissions returns the permissions granted to the API token.
+func (c *Client) GetPermissions(ctx context.Context) ([]string, error) {
+	var result permissionsResponse
+	if err := c.do(ctx, http.MethodGet, "/permissions", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Permissions, nil
+}
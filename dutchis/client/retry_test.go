@@ -0,0 +1,100 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	networkErr := errors.New("connection reset by peer")
+
+	tests := []struct {
+		name   string
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{"429 on GET", http.MethodGet, http.StatusTooManyRequests, nil, true},
+		{"429 on POST", http.MethodPost, http.StatusTooManyRequests, nil, true},
+		{"502 on GET", http.MethodGet, http.StatusBadGateway, nil, true},
+		{"503 on DELETE", http.MethodDelete, http.StatusServiceUnavailable, nil, true},
+		{"504 on PATCH", http.MethodPatch, http.StatusGatewayTimeout, nil, true},
+		{"503 on POST", http.MethodPost, http.StatusServiceUnavailable, nil, false},
+		{"500 on GET", http.MethodGet, http.StatusInternalServerError, nil, false},
+		{"404 on GET", http.MethodGet, http.StatusNotFound, nil, false},
+		{"200 on GET", http.MethodGet, http.StatusOK, nil, false},
+		{"network error on GET", http.MethodGet, 0, networkErr, true},
+		{"network error on POST", http.MethodPost, 0, networkErr, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var resp *http.Response
+			if test.status != 0 {
+				resp = &http.Response{StatusCode: test.status, Header: http.Header{}}
+			}
+			if got := shouldRetry(test.method, resp, test.err); got != test.want {
+				t.Errorf("shouldRetry(%s, %d, %v) = %t, want %t", test.method, test.status, test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter string
+		min, max   time.Duration
+	}{
+		{"first retry", 0, "", 500 * time.Millisecond, time.Second},
+		{"third retry", 2, "", 2 * time.Second, 4 * time.Second},
+		{"capped", 10, "", 15 * time.Second, 30 * time.Second},
+		{"overflowing shift", 80, "", 15 * time.Second, 30 * time.Second},
+		{"retry after seconds", 0, "7", 7 * time.Second, 7 * time.Second},
+		{"invalid retry after", 0, "soon", 500 * time.Millisecond, time.Second},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+			if test.retryAfter != "" {
+				resp.Header.Set("Retry-After", test.retryAfter)
+			}
+			for i := 0; i < 20; i++ {
+				if got := retryDelay(test.attempt, resp); got < test.min || got > test.max {
+					t.Fatalf("retryDelay(%d) = %s, want between %s and %s", test.attempt, got, test.min, test.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+		ok       bool
+	}{
+		{"empty", "", 0, 0, false},
+		{"seconds", "120", 2 * time.Minute, 2 * time.Minute, true},
+		{"zero", "0", 0, 0, true},
+		{"negative", "-5", 0, 0, false},
+		{"garbage", "tomorrow", 0, 0, false},
+		{"future date", time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 50 * time.Second, time.Minute, true},
+		{"past date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(test.value)
+			if ok != test.ok || got < test.min || got > test.max {
+				t.Errorf("parseRetryAfter(%q) = %s, %t, want between %s and %s, %t", test.value, got, ok, test.min, test.max, test.ok)
+			}
+		})
+	}
+}
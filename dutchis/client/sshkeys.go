@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SSHKey is an SSH public key stored in the team.
+type SSHKey struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+// CreateSSHKeyRequest is the payload of POST /sshkeys.
+type CreateSSHKeyRequest struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"public_key"`
+}
+
+type createSSHKeyResponse struct {
+	Envelope
+	UUID string `json:"uuid"`
+}
+
+type sshKeyResponse struct {
+	Envelope
+	Data SSHKey `json:"data"`
+}
+
+type sshKeysResponse struct {
+	Envelope
+	Data []SSHKey   `json:"data"`
+	Meta Pagination `json:"meta"`
+}
+
+// CreateSSHKey adds an SSH key to the team and returns its UUID.
+func (c *Client) CreateSSHKey(ctx context.Context, req CreateSSHKeyRequest) (string, error) {
+	var result createSSHKeyResponse
+	if err := c.do(ctx, http.MethodPost, "/sshkeys", req, &result); err != nil {
+		return "", err
+	}
+	if result.UUID == "" {
+		return "", fmt.Errorf("DutchIS API POST /sshkeys did not return the UUID of the new SSH key: %s", result.Message)
+	}
+	return result.UUID, nil
+}
+
+// ListSSHKeys returns all SSH keys of the team, following pagination.
+func (c *Client) ListSSHKeys(ctx context.Context) ([]SSHKey, error) {
+	var sshkeys []SSHKey
+	for page := 1; ; page++ {
+		var result sshKeysResponse
+		if err := c.do(ctx, http.MethodGet, pagePath("/sshkeys", page), nil, &result); err != nil {
+			return nil, err
+		}
+		sshkeys = append(sshkeys, result.Data...)
+
+		more, err := result.Meta.hasNext("/sshkeys", page)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return sshkeys, nil
+		}
+	}
+}
+
+// GetSSHKey returns the SSH key with the given UUID.
+func (c *Client) GetSSHKey(ctx context.Context, uuid string) (*SSHKey, error) {
+	var result sshKeyResponse
+	if err := c.do(ctx, http.MethodGet, "/sshkeys/"+uuid, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// DeleteSSHKey removes the SSH key with the given UUID from the team.
+func (c *Client) DeleteSSHKey(ctx context.Context, uuid string) error {
+	return c.do(ctx, http.MethodDelete, "/sshkeys/"+uuid, nil, nil)
+}
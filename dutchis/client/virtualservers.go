@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// VirtualServer is a virtual server as returned by GET /virtualservers/{uuid}.
+type VirtualServer struct {
+	UUID       string `json:"uuid"`
+	Name       string `json:"name"`
+	Class      string `json:"class"`
+	Status     string `json:"status"`
+	Node       string `json:"node"`
+	Cpus       int    `json:"cpus"`
+	Maxmem     int    `json:"maxmem"`
+	Maxdisk    int    `json:"maxdisk"`
+	Installing bool   `json:"installing,omitempty"`
+}
+
+// IPAddress is an IP address assigned to a virtual server.
+type IPAddress struct {
+	Address string `json:"address"`
+	Version int    `json:"version"`
+	Primary bool   `json:"primary"`
+}
+
+// CreateVirtualServerRequest is the payload of POST /virtualservers.
+type CreateVirtualServerRequest struct {
+	Hostname string   `json:"hostname"`
+	Class    string   `json:"class"`
+	Os       string   `json:"os"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Sshkeys  []string `json:"sshkeys"`
+	Cores    int      `json:"cores"`
+	Memory   int      `json:"memory"`
+	Network  int      `json:"network"`
+	Disk     int      `json:"disk"`
+	// UserData is base64 encoded cloud-init user data, optionally gzip compressed.
+	UserData string `json:"user_data,omitempty"`
+}
+
+// ReinstallVirtualServerRequest is the payload of POST /virtualservers/{uuid}/reinstall.
+type ReinstallVirtualServerRequest struct {
+	Os       string   `json:"os"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Sshkeys  []string `json:"sshkeys"`
+	// UserData is base64 encoded cloud-init user data, optionally gzip compressed.
+	UserData string `json:"user_data,omitempty"`
+}
+
+// UpdateVirtualServerSpecsRequest is the payload of PATCH /virtualservers/{uuid}/specs.
+// Fields left at zero are not sent and keep their current value.
+type UpdateVirtualServerSpecsRequest struct {
+	Cores   int `json:"cores,omitempty"`
+	Memory  int `json:"memory,omitempty"`
+	Network int `json:"network,omitempty"`
+	Disk    int `json:"disk,omitempty"`
+}
+
+// Power actions accepted by POST /virtualservers/{uuid}/power.
+const (
+	PowerActionStart    = "start"
+	PowerActionShutdown = "shutdown"
+	PowerActionStop     = "stop"
+	PowerActionReboot   = "reboot"
+	PowerActionReset    = "reset"
+)
+
+type powerRequest struct {
+	Action string `json:"action"`
+}
+
+type resetPasswordRequest struct {
+	Password string `json:"password"`
+}
+
+type createVirtualServerResponse struct {
+	Envelope
+	UUID string `json:"uuid"`
+}
+
+type virtualServerResponse struct {
+	Envelope
+	Data VirtualServer `json:"data"`
+}
+
+type virtualServersResponse struct {
+	Envelope
+	Data []VirtualServer `json:"data"`
+	Meta Pagination      `json:"meta"`
+}
+
+type ipAddressesResponse struct {
+	Envelope
+	Data []IPAddress `json:"data"`
+}
+
+// CreateVirtualServer orders a new virtual server and returns its UUID.
+func (c *Client) CreateVirtualServer(ctx context.Context, req CreateVirtualServerRequest) (string, error) {
+	var result createVirtualServerResponse
+	if err := c.do(ctx, http.MethodPost, "/virtualservers", req, &result); err != nil {
+		return "", err
+	}
+	if result.UUID == "" {
+		return "", fmt.Errorf("DutchIS API POST /virtualservers did not return the UUID of the new virtual server: %s", result.Message)
+	}
+	return result.UUID, nil
+}
+
+// ListVirtualServers returns all virtual servers of the team, following pagination.
+func (c *Client) ListVirtualServers(ctx context.Context) ([]VirtualServer, error) {
+	var virtualservers []VirtualServer
+	for page := 1; ; page++ {
+		var result virtualServersResponse
+		if err := c.do(ctx, http.MethodGet, pagePath("/virtualservers", page), nil, &result); err != nil {
+			return nil, err
+		}
+		virtualservers = append(virtualservers, result.Data...)
+
+		more, err := result.Meta.hasNext("/virtualservers", page)
+		if err != nil {
+			return nil, err
+		}
+		if !more {
+			return virtualservers, nil
+		}
+	}
+}
+
+// GetVirtualServer returns the virtual server with the given UUID.
+func (c *Client) GetVirtualServer(ctx context.Context, uuid string) (*VirtualServer, error) {
+	var result virtualServerResponse
+	if err := c.do(ctx, http.MethodGet, "/virtualservers/"+uuid, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Data, nil
+}
+
+// ListVirtualServerIPs returns the IP addresses assigned to the virtual server.
+func (c *Client) ListVirtualServerIPs(ctx context.Context, uuid string) ([]IPAddress, error) {
+	var result ipAddressesResponse
+	if err := c.do(ctx, http.MethodGet, "/virtualservers/"+uuid+"/ips", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// UpdateVirtualServerSpecs resizes the virtual server with the given UUID.
+func (c *Client) UpdateVirtualServerSpecs(ctx context.Context, uuid string, req UpdateVirtualServerSpecsRequest) error {
+	return c.do(ctx, http.MethodPatch, "/virtualservers/"+uuid+"/specs", req, nil)
+}
+
+// DeleteVirtualServer deletes the virtual server with the given UUID.
+func (c *Client) DeleteVirtualServer(ctx context.Context, uuid string) error {
+	return c.do(ctx, http.MethodDelete, "/virtualservers/"+uuid, nil, nil)
+}
+
+// PowerVirtualServer performs one of the PowerAction* actions on the virtual server.
+func (c *Client) PowerVirtualServer(ctx context.Context, uuid string, action string) error {
+	return c.do(ctx, http.MethodPost, "/virtualservers/"+uuid+"/power", powerRequest{Action: action}, nil)
+}
+
+// ResetVirtualServerPassword sets a new password for the default user of the virtual server.
+// The reset runs in the background, the server reports the status "resetting" meanwhile.
+func (c *Client) ResetVirtualServerPassword(ctx context.Context, uuid string, password string) error {
+	return c.do(ctx, http.MethodPost, "/virtualservers/"+uuid+"/resetpassword", resetPasswordRequest{Password: password}, nil)
+}
+
+// ReinstallVirtualServer reinstalls the operating system of the virtual server, wiping its
+// disk but keeping its UUID and IP addresses. The server reports installing until it is done.
+func (c *Client) ReinstallVirtualServer(ctx context.Context, uuid string, req ReinstallVirtualServerRequest) error {
+	return c.do(ctx, http.MethodPost, "/virtualservers/"+uuid+"/reinstall", req, nil)
+}
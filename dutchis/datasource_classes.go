@@ -0,0 +1,123 @@
+package dutchis
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceClasses() *schema.Resource {
+	classSchema := map[string]*schema.Schema{
+		"id": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The class id, to be used as class of a virtual server",
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The human readable name of the class",
+		},
+		"available": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether new virtual servers can be created in this class",
+		},
+	}
+	for _, limit := range []struct{ name, description string }{
+		{"cores", "amount of cores"},
+		{"memory", "amount of memory in GB"},
+		{"disk", "amount of storage space in GB"},
+		{"network", "network speed in Gbps"},
+	} {
+		classSchema["min_"+limit.name] = &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The minimum " + limit.description + " of a virtual server in this class",
+		}
+		classSchema["max_"+limit.name] = &schema.Schema{
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The maximum " + limit.description + " of a virtual server in this class",
+		}
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceClassesRead,
+		Schema: map[string]*schema.Schema{
+			"available_only": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only return classes new virtual servers can be created in",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The ids of the matching classes",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"classes": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching performance classes and their resource limits",
+				Elem: &schema.Resource{
+					Schema: classSchema,
+				},
+			},
+		},
+		Timeouts: resourceTimeouts(),
+	}
+}
+
+func dataSourceClassesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+
+	logger, err := CreateSubLogger("dataSourceClassesRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	classes, err := providerConfig.classes(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list performance classes")
+		return errorDiagnostics("Failed to list performance classes", err)
+	}
+
+	availableOnly := d.Get("available_only").(bool)
+
+	ids := make([]string, 0, len(classes))
+	result := make([]map[string]interface{}, 0, len(classes))
+	for _, class := range classes {
+		if availableOnly && !class.Available {
+			continue
+		}
+		ids = append(ids, class.ID)
+		result = append(result, map[string]interface{}{
+			"id":          class.ID,
+			"name":        class.Name,
+			"available":   class.Available,
+			"min_cores":   class.MinCores,
+			"max_cores":   class.MaxCores,
+			"min_memory":  class.MinMemory,
+			"max_memory":  class.MaxMemory,
+			"min_disk":    class.MinDisk,
+			"max_disk":    class.MaxDisk,
+			"min_network": class.MinNetwork,
+			"max_network": class.MaxNetwork,
+		})
+	}
+
+	d.SetId("classes/" + strconv.FormatBool(availableOnly))
+	d.Set("ids", ids)
+	if err := d.Set("classes", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msgf("Found %d of %d performance classes", len(ids), len(classes))
+	return nil
+}
@@ -0,0 +1,56 @@
+package dutchis
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/fakeapi"
+)
+
+func TestDataSourceClasses(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]interface{}
+		want   []string
+	}{
+		{"all", map[string]interface{}{}, []string{"performance", "storage", "legacy"}},
+		{"available only", map[string]interface{}{"available_only": true}, []string{"performance", "storage"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// each provider caches the classes, so every case gets its own
+			fake := newTestFakeAPI()
+			fake.PageSize = 2
+			provider := newTestProvider(t, fake)
+
+			attributes, diags := testReadDataSource(t, provider, "dutchis_classes", test.config)
+			if len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			count, _ := strconv.Atoi(attributes["ids.#"])
+			var got []string
+			for i := 0; i < count; i++ {
+				got = append(got, attributes["ids."+strconv.Itoa(i)])
+			}
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Fatalf("ids = %v, want %v", got, test.want)
+			}
+
+			storage := fakeapi.DefaultClasses[1]
+			for key, want := range map[string]string{
+				"classes.1.id":          storage.ID,
+				"classes.1.available":   "true",
+				"classes.1.min_disk":    strconv.Itoa(storage.MinDisk),
+				"classes.1.max_disk":    strconv.Itoa(storage.MaxDisk),
+				"classes.1.max_network": strconv.Itoa(storage.MaxNetwork),
+			} {
+				if got := attributes[key]; got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package dutchis
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func dataSourceOSImages() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceOSImagesRead,
+		Schema: map[string]*schema.Schema{
+			"family": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return images of this family, e.g. ubuntu or windows",
+			},
+			"latest": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Only return the latest version of each family",
+			},
+			"ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The ids of the matching images, to be used as os of a virtual server",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"images": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The matching operating system images",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The os id, to be used as os of a virtual server",
+						},
+						"name": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The human readable name of the image",
+						},
+						"family": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The family of the image, e.g. ubuntu",
+						},
+						"version": {
+							Type:        schema.TypeString,
+							Computed:    true,
+							Description: "The version of the image within its family",
+						},
+						"windows": {
+							Type:        schema.TypeBool,
+							Computed:    true,
+							Description: "Whether this is a Windows image",
+						},
+					},
+				},
+			},
+		},
+		Timeouts: resourceTimeouts(),
+	}
+}
+
+func dataSourceOSImagesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+
+	logger, err := CreateSubLogger("dataSourceOSImagesRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	images, err := providerConfig.osImages(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list operating system images")
+		return errorDiagnostics("Failed to list operating system images", err)
+	}
+
+	family := d.Get("family").(string)
+
+	var matches []client.OSImage
+	for _, image := range images {
+		if family != "" && !strings.EqualFold(image.Family, family) {
+			continue
+		}
+		matches = append(matches, image)
+	}
+
+	if d.Get("latest").(bool) {
+		matches = latestOSImages(matches)
+	}
+
+	ids := make([]string, 0, len(matches))
+	result := make([]map[string]interface{}, 0, len(matches))
+	for _, image := range matches {
+		ids = append(ids, image.ID)
+		result = append(result, map[string]interface{}{
+			"id":      image.ID,
+			"name":    image.Name,
+			"family":  image.Family,
+			"version": image.Version,
+			"windows": image.Windows,
+		})
+	}
+
+	d.SetId("os_images/" + family + "/" + strconv.FormatBool(d.Get("latest").(bool)))
+	d.Set("ids", ids)
+	if err := d.Set("images", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msgf("Found %d of %d operating system images", len(matches), len(images))
+	return nil
+}
+
+// latestOSImages keeps the newest image of every family, in catalogue order.
+func latestOSImages(images []client.OSImage) []client.OSImage {
+	latest := make(map[string]int)
+	for i, image := range images {
+		current, ok := latest[image.Family]
+		if !ok || compareVersions(image.Version, images[current].Version) > 0 {
+			latest[image.Family] = i
+		}
+	}
+
+	var result []client.OSImage
+	for i, image := range images {
+		if latest[image.Family] == i {
+			result = append(result, image)
+		}
+	}
+	return result
+}
+
+// compareVersions compares dotted version strings numerically where possible, so that
+// "22.04" > "20.04" and "12" > "9". It returns -1, 0 or 1 like strings.Compare.
+func compareVersions(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart string
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil && aNum != bNum:
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		case (aErr != nil || bErr != nil) && aPart != bPart:
+			return strings.Compare(aPart, bPart)
+		}
+	}
+	return 0
+}
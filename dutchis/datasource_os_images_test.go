@@ -0,0 +1,52 @@
+package dutchis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/client"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"22.04", "22.04", 0},
+		{"22.04", "20.04", 1},
+		{"20.04", "22.04", -1},
+		{"12", "9", 1},
+		{"9", "12", -1},
+		{"22.04.1", "22.04", 1},
+		{"22.04", "22.04.1", -1},
+		{"2019", "2022", -1},
+		{"stream9", "stream8", 1},
+		{"", "", 0},
+	}
+
+	for _, test := range tests {
+		if got := compareVersions(test.a, test.b); got != test.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
+
+func TestLatestOSImages(t *testing.T) {
+	images := []client.OSImage{
+		{ID: "ubuntu2004", Family: "ubuntu", Version: "20.04"},
+		{ID: "debian12", Family: "debian", Version: "12"},
+		{ID: "ubuntu2204", Family: "ubuntu", Version: "22.04"},
+		{ID: "debian9", Family: "debian", Version: "9"},
+		{ID: "windows2022", Family: "windows", Version: "2022", Windows: true},
+	}
+
+	got := latestOSImages(images)
+	want := []client.OSImage{images[1], images[2], images[4]}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("latestOSImages() = %v, want %v", got, want)
+	}
+
+	if got := latestOSImages(nil); len(got) != 0 {
+		t.Errorf("latestOSImages(nil) = %v, want none", got)
+	}
+}
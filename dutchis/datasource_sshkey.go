@@ -0,0 +1,125 @@
+package dutchis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// sshKeyDataSourceSchema describes an SSH key as exposed by the data sources.
+func sshKeyDataSourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"uuid": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The UUID of the SSH key, to be used in the sshkeys of a virtual server",
+		},
+		"name": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The name of the SSH key",
+		},
+		"fingerprint": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The SHA256 fingerprint of the SSH key",
+		},
+		"public_key": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The public key in OpenSSH authorized_keys format",
+		},
+	}
+}
+
+func flattenSSHKey(sshkey client.SSHKey) map[string]interface{} {
+	return map[string]interface{}{
+		"uuid":        sshkey.UUID,
+		"name":        sshkey.Name,
+		"fingerprint": sshkey.Fingerprint,
+		"public_key":  sshkey.PublicKey,
+	}
+}
+
+func dataSourceSSHKey() *schema.Resource {
+	dataSchema := sshKeyDataSourceSchema()
+
+	dataSchema["name"].Optional = true
+	dataSchema["name"].ExactlyOneOf = []string{"name", "fingerprint"}
+	dataSchema["name"].Description = "The exact name of the SSH key to look up"
+
+	dataSchema["fingerprint"].Optional = true
+	dataSchema["fingerprint"].ExactlyOneOf = []string{"name", "fingerprint"}
+	dataSchema["fingerprint"].Description = "The SHA256 fingerprint of the SSH key to look up, e.g. SHA256:..."
+
+	dataSchema["team_uuid"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.IsUUID,
+		Description:  "The team to look in, defaults to the team of the provider",
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceSSHKeyRead,
+		Schema:      dataSchema,
+		Timeouts:    resourceTimeouts(),
+	}
+}
+
+func dataSourceSSHKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("dataSourceSSHKeyRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	name := d.Get("name").(string)
+	fingerprint := d.Get("fingerprint").(string)
+
+	logger.Info().Msgf("Looking up SSH key by name %q or fingerprint %q", name, fingerprint)
+
+	sshkeys, err := apiClient.ListSSHKeys(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list SSH keys")
+		return sshKeyErrorDiagnostics("Failed to list SSH keys", err, "sshkey:read")
+	}
+
+	var matches []client.SSHKey
+	for _, sshkey := range sshkeys {
+		if (name != "" && sshkey.Name == name) || (fingerprint != "" && sshkey.Fingerprint == fingerprint) {
+			matches = append(matches, sshkey)
+		}
+	}
+
+	description := "name " + name
+	if fingerprint != "" {
+		description = "fingerprint " + fingerprint
+	}
+	switch len(matches) {
+	case 0:
+		return diag.Errorf("no SSH key with %s found", description)
+	case 1:
+	default:
+		uuids := make([]string, 0, len(matches))
+		for _, match := range matches {
+			uuids = append(uuids, match.UUID)
+		}
+		return diag.Errorf("%d SSH keys with %s found (%s), use the dutchis_sshkeys data source to get all of them",
+			len(matches), description, strings.Join(uuids, ", "))
+	}
+
+	d.SetId(matches[0].UUID)
+	d.Set("team_uuid", apiClient.TeamUUID)
+	for key, value := range flattenSSHKey(matches[0]) {
+		d.Set(key, value)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package dutchis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/client"
+)
+
+// createTestSSHKeys stores an SSH key per name through the API and returns them by UUID.
+func createTestSSHKeys(t *testing.T, apiClient *client.Client, names ...string) map[string]*client.SSHKey {
+	t.Helper()
+	ctx := context.Background()
+
+	sshkeys := make(map[string]*client.SSHKey)
+	for i, name := range names {
+		// every key needs its own data, the API refuses duplicate fingerprints
+		blob := testPublicKeyBlobWithData("ssh-ed25519", byte(i))
+		id, err := apiClient.CreateSSHKey(ctx, client.CreateSSHKeyRequest{Name: name, PublicKey: "ssh-ed25519 " + blob})
+		if err != nil {
+			t.Fatalf("creating SSH key %s: %v", name, err)
+		}
+		if sshkeys[id], err = apiClient.GetSSHKey(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return sshkeys
+}
+
+func TestDataSourceSSHKey(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+	sshkeys := createTestSSHKeys(t, provider.Meta().(*providerConfiguration).Client, "laptop", "desktop", "desktop")
+
+	var laptop *client.SSHKey
+	for _, sshkey := range sshkeys {
+		if sshkey.Name == "laptop" {
+			laptop = sshkey
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{"name", map[string]interface{}{"name": "laptop"}, ""},
+		{"fingerprint", map[string]interface{}{"fingerprint": laptop.Fingerprint}, ""},
+		{"unknown name", map[string]interface{}{"name": "phone"}, "no SSH key with name phone found"},
+		{"unknown fingerprint", map[string]interface{}{"fingerprint": "SHA256:unknown"}, "no SSH key with fingerprint SHA256:unknown found"},
+		{"duplicate name", map[string]interface{}{"name": "desktop"}, "2 SSH keys with name desktop found"},
+		{"name and fingerprint", map[string]interface{}{"name": "laptop", "fingerprint": laptop.Fingerprint}, "only one of"},
+		{"invalid team", map[string]interface{}{"name": "laptop", "team_uuid": "my-team"}, "expected \"team_uuid\" to be a valid UUID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attributes, diags := testReadDataSource(t, provider, "dutchis_sshkey", test.config)
+			if test.wantErr != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary+diags[0].Detail, test.wantErr) {
+					t.Fatalf("diagnostics = %v, want an error containing %q", diags, test.wantErr)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			for key, want := range map[string]string{
+				"id":          laptop.UUID,
+				"uuid":        laptop.UUID,
+				"name":        "laptop",
+				"fingerprint": laptop.Fingerprint,
+				"public_key":  laptop.PublicKey,
+				"team_uuid":   testTeamUUID,
+			} {
+				if got := attributes[key]; got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestDataSourceSSHKeys(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+	sshkeys := createTestSSHKeys(t, provider.Meta().(*providerConfiguration).Client, "laptop", "desktop", "desktop")
+
+	var laptop *client.SSHKey
+	for _, sshkey := range sshkeys {
+		if sshkey.Name == "laptop" {
+			laptop = sshkey
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    []string
+		wantErr string
+	}{
+		{"all", map[string]interface{}{}, []string{"desktop", "desktop", "laptop"}, ""},
+		{"names", map[string]interface{}{"names": []interface{}{"desktop", "phone"}}, []string{"desktop", "desktop"}, ""},
+		{"fingerprints", map[string]interface{}{"fingerprints": []interface{}{laptop.Fingerprint}}, []string{"laptop"}, ""},
+		{"names and fingerprints", map[string]interface{}{"names": []interface{}{"desktop"}, "fingerprints": []interface{}{laptop.Fingerprint}}, nil, ""},
+		{"invalid team", map[string]interface{}{"team_uuid": "my-team"}, nil, "expected \"team_uuid\" to be a valid UUID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attributes, diags := testReadDataSource(t, provider, "dutchis_sshkeys", test.config)
+			if test.wantErr != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary+diags[0].Detail, test.wantErr) {
+					t.Fatalf("diagnostics = %v, want an error containing %q", diags, test.wantErr)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			count, _ := strconv.Atoi(attributes["sshkeys.#"])
+			var got []string
+			for i := 0; i < count; i++ {
+				index := strconv.Itoa(i)
+				got = append(got, attributes["sshkeys."+index+".name"])
+				if uuid := attributes["uuids."+index]; sshkeys[uuid] == nil {
+					t.Errorf("uuids.%d = %q is not one of the SSH keys", i, uuid)
+				}
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("found %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package dutchis
+
+import (
+	"context"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceSSHKeys() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceSSHKeysRead,
+		Schema: map[string]*schema.Schema{
+			"team_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "The team to list the SSH keys of, defaults to the team of the provider",
+			},
+			"names": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Only return SSH keys with one of these names",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"fingerprints": {
+				Type:        schema.TypeList,
+				Optional:    true,
+				Description: "Only return SSH keys with one of these SHA256 fingerprints",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"uuids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The UUIDs of the matching SSH keys, ready to be used as sshkeys of a virtual server",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"sshkeys": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The SSH keys matching the filters",
+				Elem: &schema.Resource{
+					Schema: sshKeyDataSourceSchema(),
+				},
+			},
+		},
+		Timeouts: resourceTimeouts(),
+	}
+}
+
+func dataSourceSSHKeysRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("dataSourceSSHKeysRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var names, fingerprints []string
+	for _, name := range d.Get("names").([]interface{}) {
+		names = append(names, name.(string))
+	}
+	for _, fingerprint := range d.Get("fingerprints").([]interface{}) {
+		fingerprints = append(fingerprints, fingerprint.(string))
+	}
+
+	logger.Info().Msg("Listing SSH keys")
+
+	sshkeys, err := apiClient.ListSSHKeys(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list SSH keys")
+		return sshKeyErrorDiagnostics("Failed to list SSH keys", err, "sshkey:read")
+	}
+
+	var matches []client.SSHKey
+	for _, sshkey := range sshkeys {
+		if len(names) > 0 && !Contains(names, sshkey.Name) {
+			continue
+		}
+		if len(fingerprints) > 0 && !Contains(fingerprints, sshkey.Fingerprint) {
+			continue
+		}
+		matches = append(matches, sshkey)
+	}
+
+	uuids := make([]string, 0, len(matches))
+	result := make([]map[string]interface{}, 0, len(matches))
+	for _, sshkey := range matches {
+		uuids = append(uuids, sshkey.UUID)
+		result = append(result, flattenSSHKey(sshkey))
+	}
+
+	d.SetId(apiClient.TeamUUID)
+	d.Set("team_uuid", apiClient.TeamUUID)
+	d.Set("uuids", uuids)
+	if err := d.Set("sshkeys", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msgf("Found %d of %d SSH keys matching the filters", len(matches), len(sshkeys))
+	return nil
+}
@@ -0,0 +1,174 @@
+package dutchis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+// virtualServerDataSourceSchema describes a virtual server as exposed by the data sources,
+// i.e. everything resourceVirtualServerRead can recover from the API.
+func virtualServerDataSourceSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"uuid": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The UUID of the virtual server",
+		},
+		"hostname": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The virtual server hostname",
+		},
+		"class": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The Performance class of the virtual server",
+		},
+		"cores": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The amount of cores assigned to the virtual server",
+		},
+		"memory": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The amount of memory in GB assigned to the virtual server",
+		},
+		"disk": {
+			Type:        schema.TypeInt,
+			Computed:    true,
+			Description: "The amount of storage space in GB assigned to the virtual server",
+		},
+		"status": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The status of the virtual server as reported by the API",
+		},
+		"node": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The node the virtual server runs on",
+		},
+		"installing": {
+			Type:        schema.TypeBool,
+			Computed:    true,
+			Description: "Whether the operating system is still being installed",
+		},
+		"ipv4_address": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The primary IPv4 address of the virtual server",
+		},
+		"ipv6_address": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The primary IPv6 address of the virtual server",
+		},
+		"ip_addresses": {
+			Type:        schema.TypeList,
+			Computed:    true,
+			Description: "All IP addresses assigned to the virtual server",
+			Elem: &schema.Schema{
+				Type: schema.TypeString,
+			},
+		},
+	}
+}
+
+func dataSourceVirtualServer() *schema.Resource {
+	dataSchema := virtualServerDataSourceSchema()
+
+	dataSchema["uuid"].Optional = true
+	dataSchema["uuid"].ExactlyOneOf = []string{"uuid", "hostname"}
+	dataSchema["uuid"].ValidateFunc = validation.IsUUID
+	dataSchema["uuid"].Description = "The UUID of the virtual server to look up"
+
+	dataSchema["hostname"].Optional = true
+	dataSchema["hostname"].ExactlyOneOf = []string{"uuid", "hostname"}
+	dataSchema["hostname"].Description = "The exact hostname of the virtual server to look up"
+
+	dataSchema["team_uuid"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.IsUUID,
+		Description:  "The team to look in, defaults to the team of the provider",
+	}
+
+	return &schema.Resource{
+		ReadContext: dataSourceVirtualServerRead,
+		Schema:      dataSchema,
+		Timeouts:    resourceTimeouts(),
+	}
+}
+
+func dataSourceVirtualServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("dataSourceVirtualServerRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var virtualserver *client.VirtualServer
+	if id := d.Get("uuid").(string); id != "" {
+		logger.Info().Msg("Looking up virtual server by UUID: " + id)
+
+		virtualserver, err = apiClient.GetVirtualServer(ctx, id)
+		if client.IsNotFound(err) {
+			return diag.Errorf("no virtual server with UUID %s found", id)
+		}
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to read virtual server")
+			return errorDiagnostics("Failed to read virtual server", err)
+		}
+	} else {
+		hostname := d.Get("hostname").(string)
+		logger.Info().Msg("Looking up virtual server by hostname: " + hostname)
+
+		virtualservers, err := apiClient.ListVirtualServers(ctx)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to list virtual servers")
+			return errorDiagnostics("Failed to list virtual servers", err)
+		}
+
+		var matches []client.VirtualServer
+		for _, candidate := range virtualservers {
+			if candidate.Name == hostname {
+				matches = append(matches, candidate)
+			}
+		}
+
+		switch len(matches) {
+		case 0:
+			return diag.Errorf("no virtual server with hostname %q found", hostname)
+		case 1:
+			virtualserver = &matches[0]
+		default:
+			uuids := make([]string, 0, len(matches))
+			for _, match := range matches {
+				uuids = append(uuids, match.UUID)
+			}
+			return diag.Errorf("%d virtual servers with hostname %q found (%s), look the server up by uuid instead",
+				len(matches), hostname, strings.Join(uuids, ", "))
+		}
+	}
+
+	d.SetId(virtualserver.UUID)
+	d.Set("uuid", virtualserver.UUID)
+	d.Set("team_uuid", apiClient.TeamUUID)
+
+	diags := setVirtualServerAttributes(ctx, apiClient, d, virtualserver)
+	if len(diags) > 0 {
+		logger.Warn().Msg("Unable to read IP addresses of virtual server: " + virtualserver.UUID)
+	}
+
+	logger.Info().Msgf("Found virtual server %s (%s)", virtualserver.UUID, virtualserver.Name)
+	return diags
+}
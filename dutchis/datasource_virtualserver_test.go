@@ -0,0 +1,75 @@
+package dutchis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/client"
+)
+
+// createTestVirtualServer creates a virtual server directly through the API and returns its UUID.
+func createTestVirtualServer(t *testing.T, apiClient *client.Client, hostname string, class string) string {
+	t.Helper()
+
+	req := client.CreateVirtualServerRequest{
+		Hostname: hostname, Class: class, Os: "ubuntu2204", Username: "admin",
+		Password: "Str0ng-Passw0rd", Cores: 2, Memory: 4, Network: 1, Disk: 50,
+	}
+	if class == "storage" {
+		req.Disk = 200
+	}
+	id, err := apiClient.CreateVirtualServer(context.Background(), req)
+	if err != nil {
+		t.Fatalf("creating virtual server %s: %v", hostname, err)
+	}
+	return id
+}
+
+func TestDataSourceVirtualServer(t *testing.T) {
+	fake := newTestFakeAPI()
+	fake.InstallDuration = 0
+	provider := newTestProvider(t, fake)
+	apiClient := provider.Meta().(*providerConfiguration).Client
+
+	web := createTestVirtualServer(t, apiClient, "web-1", "performance")
+	db1 := createTestVirtualServer(t, apiClient, "db-1", "performance")
+	createTestVirtualServer(t, apiClient, "db-1", "performance")
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    string
+		wantErr string
+	}{
+		{"hostname", map[string]interface{}{"hostname": "web-1"}, web, ""},
+		{"uuid", map[string]interface{}{"uuid": db1}, db1, ""},
+		{"team", map[string]interface{}{"hostname": "web-1", "team_uuid": testTeamUUID}, web, ""},
+		{"no match", map[string]interface{}{"hostname": "mail-1"}, "", `no virtual server with hostname "mail-1" found`},
+		{"several matches", map[string]interface{}{"hostname": "db-1"}, "", "2 virtual servers with hostname \"db-1\" found"},
+		{"unknown uuid", map[string]interface{}{"uuid": testTeamUUID}, "", "no virtual server with UUID " + testTeamUUID + " found"},
+		{"hostname and uuid", map[string]interface{}{"hostname": "web-1", "uuid": web}, "", "only one of"},
+		{"invalid team", map[string]interface{}{"hostname": "web-1", "team_uuid": "my-team"}, "", "expected \"team_uuid\" to be a valid UUID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attributes, diags := testReadDataSource(t, provider, "dutchis_virtualserver", test.config)
+			if test.wantErr != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary+diags[0].Detail, test.wantErr) {
+					t.Fatalf("diagnostics = %v, want an error containing %q", diags, test.wantErr)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+			if attributes["id"] != test.want || attributes["uuid"] != test.want {
+				t.Errorf("found virtual server %s, want %s", attributes["uuid"], test.want)
+			}
+			if attributes["team_uuid"] != testTeamUUID || attributes["status"] != "running" || attributes["ipv4_address"] == "" {
+				t.Errorf("attributes of the virtual server are not set: %v", attributes)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package dutchis
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func dataSourceVirtualServers() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceVirtualServersRead,
+		Schema: map[string]*schema.Schema{
+			"team_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "The team to list the virtual servers of, defaults to the team of the provider",
+			},
+			"hostname_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+				Description:  "Only return virtual servers whose hostname matches this regular expression",
+			},
+			"class": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return virtual servers of this performance class",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return virtual servers with this status, e.g. running",
+			},
+			"node": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only return virtual servers running on this node",
+			},
+			"virtualservers": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The virtual servers matching all filters, ordered as returned by the API. The IP addresses take one extra API request per server",
+				Elem: &schema.Resource{
+					Schema: virtualServerDataSourceSchema(),
+				},
+			},
+		},
+		Timeouts: resourceTimeouts(),
+	}
+}
+
+func dataSourceVirtualServersRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("dataSourceVirtualServersRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var hostnameRegex *regexp.Regexp
+	if expr := d.Get("hostname_regex").(string); expr != "" {
+		// already checked by the ValidateFunc
+		hostnameRegex = regexp.MustCompile(expr)
+	}
+	class := d.Get("class").(string)
+	status := d.Get("status").(string)
+	node := d.Get("node").(string)
+
+	logger.Info().Msg("Listing virtual servers")
+
+	virtualservers, err := apiClient.ListVirtualServers(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list virtual servers")
+		return errorDiagnostics("Failed to list virtual servers", err)
+	}
+
+	var matches []client.VirtualServer
+	for _, virtualserver := range virtualservers {
+		if hostnameRegex != nil && !hostnameRegex.MatchString(virtualserver.Name) {
+			continue
+		}
+		if class != "" && virtualserver.Class != class {
+			continue
+		}
+		if status != "" && virtualserver.Status != status {
+			continue
+		}
+		if node != "" && virtualserver.Node != node {
+			continue
+		}
+		matches = append(matches, virtualserver)
+	}
+
+	// the list endpoint does not include IP addresses, so they are fetched with one
+	// request per matching server; narrow the filters to keep large teams fast
+	var diags diag.Diagnostics
+	var unreadable []string
+	var ipErr error
+	result := make([]map[string]interface{}, 0, len(matches))
+	for i := range matches {
+		attributes := flattenVirtualServer(&matches[i])
+		attributes["uuid"] = matches[i].UUID
+
+		ipAttributes, err := virtualServerIPAttributes(ctx, apiClient, matches[i].UUID)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Unable to read IP addresses of virtual server: " + matches[i].UUID)
+			unreadable = append(unreadable, matches[i].UUID)
+			ipErr = err
+		}
+		for key, value := range ipAttributes {
+			attributes[key] = value
+		}
+		result = append(result, attributes)
+	}
+	if len(unreadable) > 0 {
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Warning,
+			Summary:  "Unable to read IP addresses of virtual servers",
+			Detail: fmt.Sprintf("The IP addresses of %d virtual servers (%s) could not be read and are left empty, the last error was: %v",
+				len(unreadable), strings.Join(unreadable, ", "), ipErr),
+		})
+	}
+
+	d.SetId(apiClient.TeamUUID)
+	d.Set("team_uuid", apiClient.TeamUUID)
+	if err := d.Set("virtualservers", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msgf("Found %d of %d virtual servers matching the filters", len(matches), len(virtualservers))
+	return diags
+}
@@ -0,0 +1,77 @@
+package dutchis
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/client"
+)
+
+func TestDataSourceVirtualServers(t *testing.T) {
+	fake := newTestFakeAPI()
+	fake.InstallDuration = 0
+	fake.PowerDuration = 0
+	fake.PageSize = 2
+	provider := newTestProvider(t, fake)
+	apiClient := provider.Meta().(*providerConfiguration).Client
+
+	// five servers spread over three pages
+	for _, hostname := range []string{"web-1", "web-2", "web-3"} {
+		createTestVirtualServer(t, apiClient, hostname, "performance")
+	}
+	createTestVirtualServer(t, apiClient, "db-1", "storage")
+	stopped := createTestVirtualServer(t, apiClient, "db-2", "storage")
+	if err := apiClient.PowerVirtualServer(context.Background(), stopped, client.PowerActionShutdown); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  map[string]interface{}
+		want    []string
+		wantErr string
+	}{
+		{"all", map[string]interface{}{}, []string{"db-1", "db-2", "web-1", "web-2", "web-3"}, ""},
+		{"hostname regex", map[string]interface{}{"hostname_regex": "^web-[12]$"}, []string{"web-1", "web-2"}, ""},
+		{"class", map[string]interface{}{"class": "storage"}, []string{"db-1", "db-2"}, ""},
+		{"status", map[string]interface{}{"status": "stopped"}, []string{"db-2"}, ""},
+		{"node", map[string]interface{}{"node": "fake-node-1"}, []string{"db-1", "db-2", "web-1", "web-2", "web-3"}, ""},
+		{"other node", map[string]interface{}{"node": "fake-node-2"}, nil, ""},
+		{"combined", map[string]interface{}{"hostname_regex": "-1$", "class": "storage", "status": "running"}, []string{"db-1"}, ""},
+		{"invalid regex", map[string]interface{}{"hostname_regex": "web-("}, nil, "hostname_regex"},
+		{"invalid team", map[string]interface{}{"team_uuid": "my-team"}, nil, "expected \"team_uuid\" to be a valid UUID"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			attributes, diags := testReadDataSource(t, provider, "dutchis_virtualservers", test.config)
+			if test.wantErr != "" {
+				if !diags.HasError() || !strings.Contains(diags[0].Summary+diags[0].Detail, test.wantErr) {
+					t.Fatalf("diagnostics = %v, want an error containing %q", diags, test.wantErr)
+				}
+				return
+			}
+			if len(diags) > 0 {
+				t.Fatalf("unexpected diagnostics: %v", diags)
+			}
+
+			count, _ := strconv.Atoi(attributes["virtualservers.#"])
+			var got []string
+			for i := 0; i < count; i++ {
+				prefix := "virtualservers." + strconv.Itoa(i) + "."
+				got = append(got, attributes[prefix+"hostname"])
+				if attributes[prefix+"ipv4_address"] == "" {
+					t.Errorf("virtual server %s has no IPv4 address", attributes[prefix+"hostname"])
+				}
+			}
+			// the API orders by UUID
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("found %v, want %v", got, test.want)
+			}
+		})
+	}
+}
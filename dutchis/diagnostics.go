@@ -0,0 +1,40 @@
+package dutchis
+
+import (
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// errorDiagnostics turns an error into diagnostics with a human readable summary.
+// When paths are given, one diagnostic is emitted per attribute so Terraform can
+// point at the offending arguments in the configuration.
+func errorDiagnostics(summary string, err error, paths ...cty.Path) diag.Diagnostics {
+	if len(paths) == 0 {
+		return diag.Diagnostics{{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   err.Error(),
+		}}
+	}
+
+	var diags diag.Diagnostics
+	for _, path := range paths {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Error,
+			Summary:       summary,
+			Detail:        err.Error(),
+			AttributePath: path,
+		})
+	}
+	return diags
+}
+
+// warningDiagnostic returns a warning attached to the given attribute.
+func warningDiagnostic(summary string, detail string, path cty.Path) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity:      diag.Warning,
+		Summary:       summary,
+		Detail:        detail,
+		AttributePath: path,
+	}
+}
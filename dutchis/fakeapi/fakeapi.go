@@ -0,0 +1,719 @@
+// Package fakeapi implements a stateful, in-memory stand-in for the DutchIS API.
+//
+// It is meant to run the provider end-to-end without network access, either from
+// tests (through httptest) or as a standalone process (see cmd/fakeapi).
+package fakeapi
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/google/uuid"
+)
+
+// BasePath is the path prefix the fake API is served under, mirroring production.
+const BasePath = "/api/v1"
+
+// maxUserDataSize is the largest decoded user data accepted for new servers.
+const maxUserDataSize = 64 * 1024
+
+// DefaultPermissions grants everything the provider asks for.
+var DefaultPermissions = []string{
+	"virtualserver:read",
+	"virtualserver:create",
+	"virtualserver:update",
+	"virtualserver:power",
+	"virtualserver:delete",
+	"virtualserver:upgrade",
+	"sshkey:read",
+	"sshkey:create",
+	"sshkey:delete",
+}
+
+// DefaultOSImages is the operating system catalogue served by the fake API.
+var DefaultOSImages = []client.OSImage{
+	{ID: "ubuntu2004", Name: "Ubuntu 20.04 LTS", Family: "ubuntu", Version: "20.04"},
+	{ID: "ubuntu2204", Name: "Ubuntu 22.04 LTS", Family: "ubuntu", Version: "22.04"},
+	{ID: "debian11", Name: "Debian 11", Family: "debian", Version: "11"},
+	{ID: "debian12", Name: "Debian 12", Family: "debian", Version: "12"},
+	{ID: "almalinux9", Name: "AlmaLinux 9", Family: "almalinux", Version: "9"},
+	{ID: "windows2019", Name: "Windows Server 2019", Family: "windows", Version: "2019", Windows: true},
+	{ID: "windows2022", Name: "Windows Server 2022", Family: "windows", Version: "2022", Windows: true},
+}
+
+// DefaultClasses are the performance classes served by the fake API.
+var DefaultClasses = []client.Class{
+	{ID: "performance", Name: "Performance", MinCores: 1, MaxCores: 32, MinMemory: 1, MaxMemory: 128, MinDisk: 10, MaxDisk: 2000, MinNetwork: 1, MaxNetwork: 10, Available: true},
+	{ID: "storage", Name: "Storage", MinCores: 1, MaxCores: 16, MinMemory: 1, MaxMemory: 64, MinDisk: 100, MaxDisk: 20000, MinNetwork: 1, MaxNetwork: 10, Available: true},
+	{ID: "legacy", Name: "Legacy", MinCores: 1, MaxCores: 8, MinMemory: 1, MaxMemory: 32, MinDisk: 10, MaxDisk: 500, MinNetwork: 1, MaxNetwork: 1, Available: false},
+}
+
+type virtualServer struct {
+	client.VirtualServer
+	Request client.CreateVirtualServerRequest
+	IPs     []client.IPAddress
+	// the server reports itself as installing until this moment
+	InstalledAt time.Time
+	// a requested resize that is applied at UpgradedAt
+	PendingSpecs       *client.UpdateVirtualServerSpecsRequest
+	UpgradedAt         time.Time
+	statusAfterUpgrade string
+	// a power action in progress, the server reports NextStatus from NextStatusAt
+	NextStatus   string
+	NextStatusAt time.Time
+	// set once deletion was requested, the server disappears at DeletedAt
+	Deleting  bool
+	DeletedAt time.Time
+}
+
+// locked reports whether the server is busy with an operation and refuses others.
+func (vs *virtualServer) locked() bool {
+	return vs.Installing || vs.PendingSpecs != nil || vs.NextStatus != "" || vs.Deleting
+}
+
+// refresh moves the server along its lifecycle based on the current time.
+func (vs *virtualServer) refresh() {
+	if vs.Installing && !time.Now().Before(vs.InstalledAt) {
+		vs.Installing = false
+		vs.Status = "running"
+	}
+	if vs.PendingSpecs != nil && !time.Now().Before(vs.UpgradedAt) {
+		specs := vs.PendingSpecs
+		if specs.Cores != 0 {
+			vs.Cpus = specs.Cores
+			vs.Request.Cores = specs.Cores
+		}
+		if specs.Memory != 0 {
+			vs.Maxmem = specs.Memory
+			vs.Request.Memory = specs.Memory
+		}
+		if specs.Network != 0 {
+			vs.Request.Network = specs.Network
+		}
+		if specs.Disk != 0 {
+			vs.Maxdisk = specs.Disk
+			vs.Request.Disk = specs.Disk
+		}
+		vs.PendingSpecs = nil
+		vs.Status = vs.statusAfterUpgrade
+	}
+	if vs.NextStatus != "" && !time.Now().Before(vs.NextStatusAt) {
+		vs.Status = vs.NextStatus
+		vs.NextStatus = ""
+	}
+}
+
+// Server is the fake DutchIS API. The zero value is not usable, use NewServer.
+type Server struct {
+	APIToken    string
+	TeamUUID    string
+	Permissions []string
+	// InstallDuration is how long new servers report installing before they are running.
+	InstallDuration time.Duration
+	// UpgradeDuration is how long a resize takes before the new specs are reported.
+	UpgradeDuration time.Duration
+	// DeleteDuration is how long a deleted server keeps showing up.
+	DeleteDuration time.Duration
+	// PowerDuration is how long power actions take to complete.
+	PowerDuration time.Duration
+	// PasswordResetDuration is how long resetting the password of the default user takes.
+	PasswordResetDuration time.Duration
+	// PageSize is the number of items per page of list endpoints.
+	PageSize int
+	// OSImages is the operating system catalogue, os ids of new servers must be in it.
+	OSImages []client.OSImage
+	// Classes are the performance classes, new servers must fit in an available one.
+	Classes []client.Class
+
+	mu             sync.Mutex
+	virtualServers map[string]*virtualServer
+	sshKeys        map[string]*client.SSHKey
+	nextIP         int
+	failures       []int
+}
+
+// NewServer returns a fake API accepting the given token for the given team.
+func NewServer(apiToken string, teamUUID string) *Server {
+	return &Server{
+		APIToken:       apiToken,
+		TeamUUID:       teamUUID,
+		Permissions:    DefaultPermissions,
+		PageSize:       25,
+		OSImages:       DefaultOSImages,
+		Classes:        DefaultClasses,
+		virtualServers: make(map[string]*virtualServer),
+		sshKeys:        make(map[string]*client.SSHKey),
+	}
+}
+
+// Start serves the fake API on addr, or on a random local port when addr is empty.
+// The returned server must be closed by the caller; its URL plus BasePath is the
+// value to use for dutchis_api_url.
+func (s *Server) Start(addr string) (*httptest.Server, error) {
+	ts := httptest.NewUnstartedServer(s)
+	if addr != "" {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		ts.Listener.Close()
+		ts.Listener = listener
+	}
+	ts.Start()
+	return ts, nil
+}
+
+// FailNext makes the next count requests fail with the given status code, which
+// is handy to exercise retries. A 429 carries a Retry-After of one second.
+func (s *Server) FailNext(count int, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < count; i++ {
+		s.failures = append(s.failures, status)
+	}
+}
+
+func (s *Server) injectedFailure() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) == 0 {
+		return 0
+	}
+	status := s.failures[0]
+	s.failures = s.failures[1:]
+	return status
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status := s.injectedFailure(); status != 0 {
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "1")
+		}
+		writeError(w, status, http.StatusText(status))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, BasePath+"/") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.APIToken {
+		writeError(w, http.StatusUnauthorized, "invalid api token")
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, BasePath), "/"), "/")
+
+	if parts[0] == "permissions" && len(parts) == 1 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success":     true,
+			"permissions": s.Permissions,
+		})
+		return
+	}
+
+	if r.Header.Get("X-Team-Uuid") != s.TeamUUID {
+		writeError(w, http.StatusForbidden, "unknown team")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch parts[0] {
+	case "virtualservers":
+		s.serveVirtualServers(w, r, parts[1:])
+	case "sshkeys":
+		s.serveSSHKeys(w, r, parts[1:])
+	case "os":
+		if len(parts) != 1 || r.Method != http.MethodGet {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		data := make([]interface{}, 0, len(s.OSImages))
+		for _, image := range s.OSImages {
+			data = append(data, image)
+		}
+		s.writePage(w, r, data)
+	case "classes":
+		if len(parts) != 1 || r.Method != http.MethodGet {
+			writeError(w, http.StatusNotFound, "not found")
+			return
+		}
+		data := make([]interface{}, 0, len(s.Classes))
+		for _, class := range s.Classes {
+			data = append(data, class)
+		}
+		s.writePage(w, r, data)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// lookupVirtualServer returns an up to date server, forgetting it once its deletion completed.
+func (s *Server) lookupVirtualServer(id string) (*virtualServer, bool) {
+	vs, ok := s.virtualServers[id]
+	if !ok {
+		return nil, false
+	}
+	if vs.Deleting && !time.Now().Before(vs.DeletedAt) {
+		delete(s.virtualServers, id)
+		return nil, false
+	}
+	vs.refresh()
+	return vs, true
+}
+
+func (s *Server) knownOS(id string) bool {
+	for _, image := range s.OSImages {
+		if image.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// checkClass returns why the specs do not fit in the class, or an empty string if they do.
+func (s *Server) checkClass(id string, cores, memory, disk, network int) string {
+	for _, class := range s.Classes {
+		if class.ID != id {
+			continue
+		}
+		switch {
+		case !class.Available:
+			return "class " + id + " is not available"
+		case cores < class.MinCores || cores > class.MaxCores,
+			memory < class.MinMemory || memory > class.MaxMemory,
+			disk < class.MinDisk || disk > class.MaxDisk,
+			network < class.MinNetwork || network > class.MaxNetwork:
+			return "specs are out of the limits of class " + id
+		}
+		return ""
+	}
+	return "unknown class " + id
+}
+
+// allocateIPs hands out addresses from the documentation ranges (RFC 5737 and RFC 3849).
+func (s *Server) allocateIPs() []client.IPAddress {
+	s.nextIP++
+	return []client.IPAddress{
+		{Address: fmt.Sprintf("192.0.2.%d", s.nextIP%254+1), Version: 4, Primary: true},
+		{Address: fmt.Sprintf("2001:db8::%x", s.nextIP), Version: 6, Primary: true},
+	}
+}
+
+func (s *Server) serveVirtualServers(w http.ResponseWriter, r *http.Request, parts []string) {
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req client.CreateVirtualServerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Hostname == "" || req.Class == "" || req.Os == "" {
+			writeError(w, http.StatusUnprocessableEntity, "hostname, class and os are required")
+			return
+		}
+		if req.UserData != "" {
+			userData, err := base64.StdEncoding.DecodeString(req.UserData)
+			if err != nil || len(userData) > maxUserDataSize {
+				writeError(w, http.StatusUnprocessableEntity, "user_data must be base64 encoded and at most 64 KiB")
+				return
+			}
+		}
+		if !s.knownOS(req.Os) {
+			writeError(w, http.StatusUnprocessableEntity, "unknown os "+req.Os)
+			return
+		}
+		if message := s.checkClass(req.Class, req.Cores, req.Memory, req.Disk, req.Network); message != "" {
+			writeError(w, http.StatusUnprocessableEntity, message)
+			return
+		}
+
+		id := uuid.New().String()
+		vs := &virtualServer{
+			VirtualServer: client.VirtualServer{
+				UUID:       id,
+				Name:       req.Hostname,
+				Class:      req.Class,
+				Status:     "stopped",
+				Node:       "fake-node-1",
+				Cpus:       req.Cores,
+				Maxmem:     req.Memory,
+				Maxdisk:    req.Disk,
+				Installing: true,
+			},
+			Request:     req,
+			IPs:         s.allocateIPs(),
+			InstalledAt: time.Now().Add(s.InstallDuration),
+		}
+		vs.refresh()
+		s.virtualServers[id] = vs
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"message": "Virtual server created",
+			"uuid":    id,
+		})
+
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		ids := make([]string, 0, len(s.virtualServers))
+		for id := range s.virtualServers {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		data := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			if vs, ok := s.lookupVirtualServer(id); ok {
+				data = append(data, vs.VirtualServer)
+			}
+		}
+		s.writePage(w, r, data)
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    vs.VirtualServer,
+		})
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		if vs.locked() {
+			writeError(w, http.StatusConflict, "virtual server is locked")
+			return
+		}
+		vs.Deleting = true
+		vs.DeletedAt = time.Now().Add(s.DeleteDuration)
+		vs.Status = "deleting"
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Virtual server deleted",
+		})
+
+	case len(parts) == 2 && parts[1] == "specs" && r.Method == http.MethodPatch:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		var req client.UpdateVirtualServerSpecsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if vs.locked() {
+			writeError(w, http.StatusConflict, "virtual server is locked")
+			return
+		}
+		if req.Disk != 0 && req.Disk < vs.Maxdisk {
+			writeError(w, http.StatusUnprocessableEntity, "disk cannot be shrunk")
+			return
+		}
+		vs.PendingSpecs = &req
+		vs.UpgradedAt = time.Now().Add(s.UpgradeDuration)
+		vs.statusAfterUpgrade = vs.Status
+		vs.Status = "upgrading"
+		vs.refresh()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Virtual server upgraded",
+		})
+
+	case len(parts) == 2 && parts[1] == "ips" && r.Method == http.MethodGet:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    vs.IPs,
+		})
+
+	case len(parts) == 2 && parts[1] == "power" && r.Method == http.MethodPost:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		var req struct {
+			Action string `json:"action"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if vs.locked() {
+			writeError(w, http.StatusConflict, "virtual server is locked")
+			return
+		}
+
+		switch req.Action {
+		case client.PowerActionStart:
+			vs.Status, vs.NextStatus = "starting", "running"
+		case client.PowerActionShutdown, client.PowerActionStop:
+			vs.Status, vs.NextStatus = "stopping", "stopped"
+		case client.PowerActionReboot, client.PowerActionReset:
+			if vs.Status != "running" {
+				writeError(w, http.StatusConflict, "virtual server is not running")
+				return
+			}
+			vs.Status, vs.NextStatus = "rebooting", "running"
+		default:
+			writeError(w, http.StatusUnprocessableEntity, "unknown power action")
+			return
+		}
+		vs.NextStatusAt = time.Now().Add(s.PowerDuration)
+		vs.refresh()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Power action " + req.Action + " started",
+		})
+
+	case len(parts) == 2 && parts[1] == "reinstall" && r.Method == http.MethodPost:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		var req client.ReinstallVirtualServerRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if !s.knownOS(req.Os) {
+			writeError(w, http.StatusUnprocessableEntity, "unknown os "+req.Os)
+			return
+		}
+		if vs.locked() {
+			writeError(w, http.StatusConflict, "virtual server is locked")
+			return
+		}
+
+		vs.Request.Os = req.Os
+		vs.Request.Username = req.Username
+		vs.Request.Password = req.Password
+		vs.Request.Sshkeys = req.Sshkeys
+		vs.Request.UserData = req.UserData
+		vs.Status = "stopped"
+		vs.Installing = true
+		vs.InstalledAt = time.Now().Add(s.InstallDuration)
+		vs.refresh()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Virtual server reinstall started",
+		})
+
+	case len(parts) == 2 && parts[1] == "resetpassword" && r.Method == http.MethodPost:
+		vs, ok := s.lookupVirtualServer(parts[0])
+		if !ok {
+			writeError(w, http.StatusNotFound, "virtual server not found")
+			return
+		}
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		if req.Password == "" {
+			writeError(w, http.StatusUnprocessableEntity, "password is required")
+			return
+		}
+		if vs.locked() {
+			writeError(w, http.StatusConflict, "virtual server is locked")
+			return
+		}
+
+		vs.Request.Password = req.Password
+		vs.Status, vs.NextStatus = "resetting", vs.Status
+		vs.NextStatusAt = time.Now().Add(s.PasswordResetDuration)
+		vs.refresh()
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "Password reset started",
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// sshKeyPermissions are the permissions the SSH key endpoints require, by request method.
+var sshKeyPermissions = map[string]string{
+	http.MethodGet:    "sshkey:read",
+	http.MethodPost:   "sshkey:create",
+	http.MethodDelete: "sshkey:delete",
+}
+
+func (s *Server) hasPermission(permission string) bool {
+	for _, granted := range s.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) serveSSHKeys(w http.ResponseWriter, r *http.Request, parts []string) {
+	if permission, ok := sshKeyPermissions[r.Method]; ok && !s.hasPermission(permission) {
+		writeError(w, http.StatusForbidden, "missing permission "+permission)
+		return
+	}
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodPost:
+		var req client.CreateSSHKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+		fingerprint, err := sshFingerprint(req.PublicKey)
+		if req.Name == "" || err != nil {
+			writeError(w, http.StatusUnprocessableEntity, "name and a valid public_key are required")
+			return
+		}
+		for _, key := range s.sshKeys {
+			if key.Fingerprint == fingerprint {
+				writeError(w, http.StatusConflict, "ssh key already exists")
+				return
+			}
+		}
+
+		// only the key type and data are stored, the comment is dropped
+		id := uuid.New().String()
+		s.sshKeys[id] = &client.SSHKey{
+			UUID:        id,
+			Name:        req.Name,
+			PublicKey:   strings.Join(strings.Fields(req.PublicKey)[:2], " "),
+			Fingerprint: fingerprint,
+		}
+		writeJSON(w, http.StatusCreated, map[string]interface{}{
+			"success": true,
+			"message": "SSH key created",
+			"uuid":    id,
+		})
+
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		ids := make([]string, 0, len(s.sshKeys))
+		for id := range s.sshKeys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		data := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			data = append(data, s.sshKeys[id])
+		}
+		s.writePage(w, r, data)
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		key, ok := s.sshKeys[parts[0]]
+		if !ok {
+			writeError(w, http.StatusNotFound, "ssh key not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    key,
+		})
+
+	case len(parts) == 1 && r.Method == http.MethodDelete:
+		if _, ok := s.sshKeys[parts[0]]; !ok {
+			writeError(w, http.StatusNotFound, "ssh key not found")
+			return
+		}
+		delete(s.sshKeys, parts[0])
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"message": "SSH key deleted",
+		})
+
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+// sshFingerprint returns the OpenSSH SHA256 fingerprint of an authorized_keys line.
+func sshFingerprint(publicKey string) (string, error) {
+	fields := strings.Fields(publicKey)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("not an OpenSSH public key")
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(blob)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
+
+// writePage writes the page of items requested through the page query parameter.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []interface{}) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	lastPage := (len(items) + s.PageSize - 1) / s.PageSize
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	start := (page - 1) * s.PageSize
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + s.PageSize
+	if end > len(items) {
+		end = len(items)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    items[start:end],
+		"meta": client.Pagination{
+			CurrentPage: page,
+			LastPage:    lastPage,
+			Total:       len(items),
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", uuid.New().String())
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"success": false,
+		"message": message,
+	})
+}
@@ -1,24 +1,29 @@
 package dutchis
 
 import (
+	"context"
 	"fmt"
-    "net/http"
-    "encoding/json"
-    "io/ioutil"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
 
 type providerConfiguration struct {
-	MaxParallel                        int
-	CurrentParallel                    int
-	Mutex                              *sync.Mutex
-	Cond                               *sync.Cond
-	LogFile                            string
-	LogLevels                          map[string]string
-	TeamUUID 						   string
-	APIToken 						   string
+	MaxParallel     int
+	CurrentParallel int
+	Mutex           *sync.Mutex
+	Cond            *sync.Cond
+	LogFile         string
+	LogLevels       map[string]string
+	Client          *client.Client
+	CatalogueMutex  sync.Mutex
+	OSImages        []client.OSImage
+	Classes         []client.Class
 }
 
 // Provider - Terrafrom properties for dutchis
@@ -38,6 +43,24 @@ func Provider() *schema.Provider {
 				Description: "API Secret",
 				Sensitive:   true,
 			},
+			"dutchis_api_url": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("DUTCHIS_API_URL", client.DefaultBaseURL),
+				Description: "Base URL of the DutchIS API, useful to target a staging or fake API server.",
+			},
+			"dutchis_max_retries": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     client.DefaultMaxRetries,
+				Description: "Maximum number of times a failed API request is retried",
+			},
+			"dutchis_max_retry_wait": {
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Default:     int(client.DefaultMaxRetryWait / time.Second),
+				Description: "Maximum number of seconds to wait in total between retries of a single API request",
+			},
 			"dutchis_log_enable": {
 				Type:        schema.TypeBool,
 				Optional:    true,
@@ -63,14 +86,24 @@ func Provider() *schema.Provider {
 		},
 
 		ResourcesMap: map[string]*schema.Resource{
-			"dutchis_virtualserver":  resourceVirtualServer(),
+			"dutchis_virtualserver": resourceVirtualServer(),
+			"dutchis_sshkey":        resourceSSHKey(),
+		},
+
+		DataSourcesMap: map[string]*schema.Resource{
+			"dutchis_virtualserver":  dataSourceVirtualServer(),
+			"dutchis_virtualservers": dataSourceVirtualServers(),
+			"dutchis_sshkey":         dataSourceSSHKey(),
+			"dutchis_sshkeys":        dataSourceSSHKeys(),
+			"dutchis_os_images":      dataSourceOSImages(),
+			"dutchis_classes":        dataSourceClasses(),
 		},
 
-		ConfigureFunc: providerConfigure,
+		ConfigureContextFunc: providerConfigure,
 	}
 }
 
-func providerConfigure(d *schema.ResourceData) (interface{}, error) {
+func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	// Minimum permissions check
 	minimumPermissions := []string{
 		"virtualserver:read",
@@ -81,36 +114,30 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		"virtualserver:upgrade",
 	}
 
-	req, err := http.NewRequest("GET", "https://dutchis.net/api/v1/permissions", nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add("Authorization", "Bearer "+d.Get("dutchis_api_token").(string))
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	
-    defer resp.Body.Close()
-    body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	apiClient := client.New(d.Get("dutchis_team_uuid").(string), d.Get("dutchis_api_token").(string))
+	apiClient.BaseURL = strings.TrimSuffix(d.Get("dutchis_api_url").(string), "/")
+	apiClient.MaxRetries = d.Get("dutchis_max_retries").(int)
+	apiClient.MaxRetryWait = time.Duration(d.Get("dutchis_max_retry_wait").(int)) * time.Second
 
-	type Permissions struct {
-		Success bool `json:"success"`
-		Permissions []string `json:"permissions"`
+	permissions, err := apiClient.GetPermissions(ctx)
+	if err != nil {
+		return nil, errorDiagnostics("Failed to check DutchIS API permissions", err, cty.GetAttrPath("dutchis_api_token"))
 	}
-    var result Permissions
-    if err := json.Unmarshal(body, &result); err != nil { 
-        return nil, err
-    }
 
+	var diags diag.Diagnostics
 	for _, permission := range minimumPermissions {
-		if !Contains(result.Permissions, permission) {
-			return nil, fmt.Errorf("missing permission %v", permission)
+		if !Contains(permissions, permission) {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "Missing API token permission",
+				Detail:        fmt.Sprintf("The API token is missing the %v permission.", permission),
+				AttributePath: cty.GetAttrPath("dutchis_api_token"),
+			})
 		}
-    }
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
 
 	logLevels := make(map[string]string)
 	for logger, level := range d.Get("dutchis_log_levels").(map[string]interface{}) {
@@ -118,7 +145,7 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 		if ok {
 			logLevels[logger] = levelAsString
 		} else {
-			return nil, fmt.Errorf("invalid logging level %v for %v. Be sure to use a string", level, logger)
+			return nil, diag.Errorf("invalid logging level %v for %v. Be sure to use a string", level, logger)
 		}
 	}
 
@@ -131,35 +158,110 @@ func providerConfigure(d *schema.ResourceData) (interface{}, error) {
 	)
 
 	var mut sync.Mutex
-	return &providerConfiguration{
-		MaxParallel:                        d.Get("dutchis_parallel").(int),
-		CurrentParallel:                    0,
-		Mutex:                              &mut,
-		Cond:                               sync.NewCond(&mut),
-		LogFile:                            d.Get("dutchis_log_file").(string),
-		LogLevels:                          logLevels,
-		TeamUUID: 						    d.Get("dutchis_team_uuid").(string),
-		APIToken: 						    d.Get("dutchis_api_token").(string),
-	}, nil
+	providerConfig := &providerConfiguration{
+		MaxParallel:     d.Get("dutchis_parallel").(int),
+		CurrentParallel: 0,
+		Mutex:           &mut,
+		Cond:            sync.NewCond(&mut),
+		LogFile:         d.Get("dutchis_log_file").(string),
+		LogLevels:       logLevels,
+		Client:          apiClient,
+	}
+	apiClient.Limiter = apiLimiter{conf: providerConfig}
+
+	return providerConfig, nil
+}
+
+// clientForTeam returns the API client for the given team, falling back to the
+// provider team when teamUUID is empty.
+func (conf *providerConfiguration) clientForTeam(teamUUID string) *client.Client {
+	if teamUUID == "" || teamUUID == conf.Client.TeamUUID {
+		return conf.Client
+	}
+	return conf.Client.WithTeam(teamUUID)
+}
+
+// osImages returns the operating system catalogue, which is fetched once and then cached.
+func (conf *providerConfiguration) osImages(ctx context.Context) ([]client.OSImage, error) {
+	conf.CatalogueMutex.Lock()
+	defer conf.CatalogueMutex.Unlock()
+
+	if conf.OSImages == nil {
+		images, err := conf.Client.ListOSImages(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conf.OSImages = images
+	}
+	return conf.OSImages, nil
+}
+
+// classes returns the performance classes, which are fetched once and then cached.
+func (conf *providerConfiguration) classes(ctx context.Context) ([]client.Class, error) {
+	conf.CatalogueMutex.Lock()
+	defer conf.CatalogueMutex.Unlock()
+
+	if conf.Classes == nil {
+		classes, err := conf.Client.ListClasses(ctx)
+		if err != nil {
+			return nil, err
+		}
+		conf.Classes = classes
+	}
+	return conf.Classes, nil
+}
+
+// isWindowsOS reports whether an os id refers to a Windows image. The catalogue is
+// authoritative, ids that are not in it fall back to the naming, e.g. "windows2022".
+func (conf *providerConfiguration) isWindowsOS(ctx context.Context, osID string) bool {
+	if images, err := conf.osImages(ctx); err == nil {
+		for _, image := range images {
+			if image.ID == osID {
+				return image.Windows
+			}
+		}
+	}
+	return strings.HasPrefix(strings.ToLower(osID), "windows")
 }
 
 type apiLockHolder struct {
 	locked bool
-	conf  *providerConfiguration
+	conf   *providerConfiguration
 }
 
-func (lock *apiLockHolder) lock() {
+func (lock *apiLockHolder) lock(ctx context.Context) error {
 	if lock.locked {
-		return
+		return nil
 	}
-	lock.locked = true
 	conf := lock.conf
+
+	// sync.Cond knows nothing about contexts, so wake up all waiters when ours is
+	// cancelled and let them check for themselves
+	waiting := make(chan struct{})
+	defer close(waiting)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conf.Mutex.Lock()
+			conf.Cond.Broadcast()
+			conf.Mutex.Unlock()
+		case <-waiting:
+		}
+	}()
+
 	conf.Mutex.Lock()
+	defer conf.Mutex.Unlock()
 	for conf.CurrentParallel >= conf.MaxParallel {
+		if err := ctx.Err(); err != nil {
+			// we may have consumed a signal meant for another waiter, pass it on
+			conf.Cond.Signal()
+			return err
+		}
 		conf.Cond.Wait()
 	}
 	conf.CurrentParallel++
-	conf.Mutex.Unlock()
+	lock.locked = true
+	return nil
 }
 
 func (lock *apiLockHolder) unlock() {
@@ -174,11 +276,27 @@ func (lock *apiLockHolder) unlock() {
 	conf.Mutex.Unlock()
 }
 
-func parallelBegin(conf *providerConfiguration) *apiLockHolder {
+func parallelBegin(ctx context.Context, conf *providerConfiguration) (*apiLockHolder, error) {
 	lock := &apiLockHolder{
-		conf:  conf,
+		conf:   conf,
 		locked: false,
 	}
-	lock.lock()
-	return lock
+	if err := lock.lock(ctx); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// apiLimiter lets the API client take an apiLockHolder slot per request, so that
+// requests backing off before a retry do not keep a slot busy.
+type apiLimiter struct {
+	conf *providerConfiguration
+}
+
+func (limiter apiLimiter) Acquire(ctx context.Context) (func(), error) {
+	lock, err := parallelBegin(ctx, limiter.conf)
+	if err != nil {
+		return nil, err
+	}
+	return lock.unlock, nil
 }
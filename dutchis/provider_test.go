@@ -0,0 +1,216 @@
+package dutchis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/fakeapi"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+const testTeamUUID = "11111111-2222-3333-4444-555555555555"
+
+func init() {
+	// the fake API completes operations in milliseconds
+	waiterDelay = 10 * time.Millisecond
+	waiterMinTimeout = 20 * time.Millisecond
+	restartStartTimeout = 500 * time.Millisecond
+}
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestFakeAPI returns a fake API whose operations take a few polls to complete.
+func newTestFakeAPI() *fakeapi.Server {
+	fake := fakeapi.NewServer("test-token", testTeamUUID)
+	fake.InstallDuration = 100 * time.Millisecond
+	fake.UpgradeDuration = 100 * time.Millisecond
+	fake.DeleteDuration = 100 * time.Millisecond
+	fake.PowerDuration = 100 * time.Millisecond
+	fake.PasswordResetDuration = 100 * time.Millisecond
+	return fake
+}
+
+// newTestProvider returns a provider configured against handler, usually a fake API.
+func newTestProvider(t *testing.T, handler http.Handler) *schema.Provider {
+	t.Helper()
+	return newTestProviderWithConfig(t, handler, nil)
+}
+
+// newTestProviderWithConfig is newTestProvider with overrides of the provider configuration.
+func newTestProviderWithConfig(t *testing.T, handler http.Handler, overrides map[string]interface{}) *schema.Provider {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	config := map[string]interface{}{
+		"dutchis_team_uuid":   testTeamUUID,
+		"dutchis_api_token":   "test-token",
+		"dutchis_api_url":     server.URL + fakeapi.BasePath,
+		"dutchis_max_retries": 0,
+		"dutchis_log_levels":  map[string]interface{}{},
+		"dutchis_parallel":    2,
+	}
+	for key, value := range overrides {
+		config[key] = value
+	}
+
+	provider := Provider()
+	diags := provider.Configure(context.Background(), terraform.NewResourceConfigRaw(config))
+	if diags.HasError() {
+		t.Fatalf("configuring the provider: %v", diags)
+	}
+	return provider
+}
+
+// testApply plans config against state and applies the plan like Terraform does,
+// replacing the resource when the plan requires it.
+func testApply(t *testing.T, provider *schema.Provider, name string, state *terraform.InstanceState, config map[string]interface{}) (*terraform.InstanceState, diag.Diagnostics) {
+	t.Helper()
+
+	ctx := context.Background()
+	resource := provider.ResourcesMap[name]
+	resourceConfig := terraform.NewResourceConfigRaw(config)
+
+	if diags := resource.Validate(resourceConfig); diags.HasError() {
+		return state, diags
+	}
+	instanceDiff, err := resource.SimpleDiff(ctx, state, resourceConfig, provider.Meta())
+	if err != nil {
+		return state, diag.FromErr(err)
+	}
+	if instanceDiff == nil || instanceDiff.Empty() {
+		return state, nil
+	}
+
+	if instanceDiff.RequiresNew() && state != nil {
+		if _, diags := resource.Apply(ctx, state, &terraform.InstanceDiff{Destroy: true}, provider.Meta()); diags.HasError() {
+			return state, diags
+		}
+		state = nil
+		if instanceDiff, err = resource.SimpleDiff(ctx, nil, resourceConfig, provider.Meta()); err != nil {
+			return state, diag.FromErr(err)
+		}
+	}
+
+	// Terraform passes the configured timeouts along with the plan
+	timeouts := &schema.ResourceTimeout{}
+	if err := timeouts.ConfigDecode(resource, resourceConfig); err != nil {
+		return state, diag.FromErr(err)
+	}
+	if err := timeouts.DiffEncode(instanceDiff); err != nil {
+		return state, diag.FromErr(err)
+	}
+	return resource.Apply(ctx, state, instanceDiff, provider.Meta())
+}
+
+// testPlan returns the plan of config against state.
+func testPlan(t *testing.T, provider *schema.Provider, name string, state *terraform.InstanceState, config map[string]interface{}) *terraform.InstanceDiff {
+	t.Helper()
+
+	instanceDiff, err := provider.ResourcesMap[name].SimpleDiff(context.Background(), state, terraform.NewResourceConfigRaw(config), provider.Meta())
+	if err != nil {
+		t.Fatalf("planning %s: %v", name, err)
+	}
+	return instanceDiff
+}
+
+func TestProviderWithoutSSHKeyPermissions(t *testing.T) {
+	fake := newTestFakeAPI()
+	fake.Permissions = []string{
+		"virtualserver:read",
+		"virtualserver:create",
+		"virtualserver:update",
+		"virtualserver:power",
+		"virtualserver:delete",
+		"virtualserver:upgrade",
+	}
+	// newTestProvider fails the test if the provider cannot be configured
+	provider := newTestProvider(t, fake)
+
+	dataSource := provider.DataSourcesMap["dutchis_sshkeys"]
+	diags := dataSource.ReadContext(context.Background(), dataSource.Data(nil), provider.Meta())
+	if !diags.HasError() || !strings.Contains(diags[0].Detail, "requires the sshkey:read permission") {
+		t.Fatalf("expected an error naming the missing permission, got %v", diags)
+	}
+}
+
+func TestProviderReleasesSlotWhileBackingOff(t *testing.T) {
+	fake := newTestFakeAPI()
+	served := make(chan struct{}, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.ServeHTTP(w, r)
+		if strings.HasSuffix(r.URL.Path, "/sshkeys") {
+			select {
+			case served <- struct{}{}:
+			default:
+			}
+		}
+	})
+	provider := newTestProviderWithConfig(t, handler, map[string]interface{}{
+		"dutchis_max_retries": 1,
+		"dutchis_parallel":    1,
+	})
+	apiClient := provider.Meta().(*providerConfiguration).Client
+	ctx := context.Background()
+
+	// the first caller is told to come back after a second and backs off
+	fake.FailNext(1, http.StatusTooManyRequests)
+	backingOff := make(chan error, 1)
+	go func() {
+		_, err := apiClient.ListSSHKeys(ctx)
+		backingOff <- err
+	}()
+	<-served
+
+	// the only slot is free in the meantime, so the second caller is not held up
+	start := time.Now()
+	if _, err := apiClient.ListVirtualServers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= 500*time.Millisecond {
+		t.Errorf("second request took %s while the first one was backing off", elapsed)
+	}
+	select {
+	case err := <-backingOff:
+		t.Fatalf("first request finished before its Retry-After: %v", err)
+	default:
+	}
+
+	if err := <-backingOff; err != nil {
+		t.Fatalf("first request failed after backing off: %v", err)
+	}
+}
+
+// testReadDataSource reads the data source with config like Terraform does and returns the
+// resulting attributes.
+func testReadDataSource(t *testing.T, provider *schema.Provider, name string, config map[string]interface{}) (map[string]string, diag.Diagnostics) {
+	t.Helper()
+
+	ctx := context.Background()
+	dataSource := provider.DataSourcesMap[name]
+	resourceConfig := terraform.NewResourceConfigRaw(config)
+
+	if diags := dataSource.Validate(resourceConfig); diags.HasError() {
+		return nil, diags
+	}
+	instanceDiff, err := dataSource.Diff(ctx, nil, resourceConfig, provider.Meta())
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
+	state, diags := dataSource.ReadDataApply(ctx, instanceDiff, provider.Meta())
+	if state == nil {
+		return nil, diags
+	}
+	return state.Attributes, diags
+}
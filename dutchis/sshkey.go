@@ -0,0 +1,173 @@
+package dutchis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+)
+
+func resourceSSHKey() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSSHKeyCreate,
+		ReadContext:   resourceSSHKeyRead,
+		DeleteContext: resourceSSHKeyDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: importTeamScopedResource,
+		},
+
+		Schema: map[string]*schema.Schema{
+			"team_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "The team the SSH key belongs to, defaults to the team of the provider",
+			},
+			"name": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "The name of the SSH key",
+			},
+			"public_key": {
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateOpenSSHPublicKey,
+				DiffSuppressFunc: suppressEquivalentSSHPublicKey,
+				Description:      "The public key in OpenSSH authorized_keys format",
+			},
+			"uuid": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The UUID of the SSH key, to be used in the sshkeys of a virtual server",
+			},
+			"fingerprint": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The SHA256 fingerprint of the SSH key",
+			},
+		},
+		Timeouts: resourceTimeouts(),
+	}
+}
+
+func resourceSSHKeyCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceSSHKeyCreate")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msg("Creating new SSH key: " + d.Get("name").(string))
+
+	id, err := apiClient.CreateSSHKey(ctx, client.CreateSSHKeyRequest{
+		Name:      d.Get("name").(string),
+		PublicKey: d.Get("public_key").(string),
+	})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create SSH key")
+		return sshKeyErrorDiagnostics("Failed to create SSH key", err, "sshkey:create", cty.GetAttrPath("public_key"))
+	}
+
+	d.SetId(id)
+
+	logger.Info().Msg("Created new SSH key: " + id)
+	return resourceSSHKeyRead(ctx, d, meta)
+}
+
+func resourceSSHKeyRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceSSHKeyRead")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msg("Reading SSH key: " + d.Id())
+
+	sshkey, err := apiClient.GetSSHKey(ctx, d.Id())
+	if client.IsNotFound(err) {
+		logger.Warn().Msg("SSH key no longer exists, removing it from state: " + d.Id())
+		diags := diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "SSH key not found",
+			Detail:   fmt.Sprintf("SSH key %s no longer exists and has been removed from the state, it will be recreated on the next apply.", d.Id()),
+		}}
+		d.SetId("")
+		return diags
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to read SSH key")
+		return sshKeyErrorDiagnostics("Failed to read SSH key", err, "sshkey:read")
+	}
+
+	d.Set("team_uuid", apiClient.TeamUUID)
+	d.Set("uuid", sshkey.UUID)
+	d.Set("name", sshkey.Name)
+	d.Set("public_key", sshkey.PublicKey)
+	d.Set("fingerprint", sshkey.Fingerprint)
+
+	return nil
+}
+
+func resourceSSHKeyDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceSSHKeyDelete")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	logger.Info().Msg("Deleting SSH key: " + d.Id())
+
+	err = apiClient.DeleteSSHKey(ctx, d.Id())
+	if client.IsNotFound(err) {
+		logger.Info().Msg("SSH key was already deleted: " + d.Id())
+		return nil
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to delete SSH key")
+		return sshKeyErrorDiagnostics("Failed to delete SSH key", err, "sshkey:delete")
+	}
+
+	return nil
+}
+
+// suppressEquivalentSSHPublicKey ignores differences in comments and surrounding
+// whitespace, which the API does not necessarily preserve.
+func suppressEquivalentSSHPublicKey(k, old, new string, d *schema.ResourceData) bool {
+	oldKey, err := parseOpenSSHPublicKey(old)
+	if err != nil {
+		return false
+	}
+	newKey, err := parseOpenSSHPublicKey(new)
+	if err != nil {
+		return false
+	}
+	return oldKey == newKey
+}
+
+// sshKeyErrorDiagnostics is errorDiagnostics for SSH key requests. The provider only checks
+// the virtual server permissions when it is configured, so that tokens without access to SSH
+// keys keep working, and a 403 is explained here instead.
+func sshKeyErrorDiagnostics(summary string, err error, permission string, paths ...cty.Path) diag.Diagnostics {
+	diags := errorDiagnostics(summary, err, paths...)
+	if client.IsStatus(err, http.StatusForbidden) {
+		for i := range diags {
+			diags[i].Detail += fmt.Sprintf("\n\nManaging SSH keys requires the %s permission, check that the API token has it.", permission)
+		}
+	}
+	return diags
+}
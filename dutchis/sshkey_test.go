@@ -0,0 +1,68 @@
+package dutchis
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestResourceSSHKeyLifecycle(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+	resource := provider.ResourcesMap["dutchis_sshkey"]
+	apiClient := provider.Meta().(*providerConfiguration).Client
+	ctx := context.Background()
+
+	blob := testPublicKeyBlob("ssh-ed25519")
+	config := map[string]interface{}{
+		"name":       "laptop",
+		"public_key": "ssh-ed25519 " + blob + " admin@laptop",
+	}
+
+	state, diags := testApply(t, provider, "dutchis_sshkey", nil, config)
+	if diags.HasError() {
+		t.Fatalf("create failed: %v", diags)
+	}
+	data, _ := base64.StdEncoding.DecodeString(blob)
+	sum := sha256.Sum256(data)
+	for key, want := range map[string]string{
+		"uuid":        state.ID,
+		"team_uuid":   testTeamUUID,
+		"name":        "laptop",
+		"public_key":  "ssh-ed25519 " + blob,
+		"fingerprint": "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+	} {
+		if got := state.Attributes[key]; got != want {
+			t.Errorf("after create %s = %q, want %q", key, got, want)
+		}
+	}
+
+	// the API dropped the comment, which must not show up as a change
+	if plan := testPlan(t, provider, "dutchis_sshkey", state, config); !plan.Empty() {
+		t.Fatalf("plan after create is not empty: %v", plan)
+	}
+
+	imported, err := resource.Importer.StateContext(ctx, resource.Data(&terraform.InstanceState{ID: state.ID}), provider.Meta())
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if diags := resource.ReadContext(ctx, imported[0], provider.Meta()); diags.HasError() {
+		t.Fatalf("read after import failed: %v", diags)
+	}
+	if got := imported[0].State(); got.Attributes["fingerprint"] != state.Attributes["fingerprint"] || got.Attributes["name"] != "laptop" {
+		t.Errorf("imported state does not match the SSH key: %v", got.Attributes)
+	}
+
+	for i := 0; i < 2; i++ {
+		// the second delete finds the key already gone
+		if _, diags := resource.Apply(ctx, state, &terraform.InstanceDiff{Destroy: true}, provider.Meta()); diags.HasError() {
+			t.Fatalf("destroy %d failed: %v", i+1, diags)
+		}
+	}
+	if _, err := apiClient.GetSSHKey(ctx, state.ID); !client.IsNotFound(err) {
+		t.Fatalf("SSH key still exists after destroy: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package dutchis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// maxUserDataSize is the largest user data, after base64 decoding, the API accepts.
+const maxUserDataSize = 64 * 1024
+
+// gzipMagic are the first bytes of every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// cloudInitPrefixes start the user data formats cloud-init recognises. None of them is
+// valid base64, so they also tell encoded user data apart from plain text.
+var cloudInitPrefixes = []string{
+	"#cloud-config",
+	"#!",
+	"#include",
+	"#cloud-boothook",
+	"#part-handler",
+	"## template: jinja",
+	"Content-Type: multipart/",
+	"MIME-Version:",
+}
+
+// isEncodedUserData reports whether data, decoded from base64, is user data rather than
+// plain text that happens to be valid base64.
+func isEncodedUserData(data []byte) bool {
+	if bytes.HasPrefix(data, gzipMagic) {
+		return true
+	}
+	for _, prefix := range cloudInitPrefixes {
+		if bytes.HasPrefix(data, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// encodeUserData turns the user_data argument into the base64 payload of the create
+// request. The argument is either plain text, like a #cloud-config document, or base64
+// as produced by base64encode, filebase64 and base64gzip. Base64 is only decoded when the
+// result is gzip compressed or starts like a cloud-init document, other values are sent
+// as plain text. Gzip compressed data is sent as is, cloud-init decompresses it on first boot.
+func encodeUserData(value string) (string, error) {
+	data := []byte(value)
+	if decoded, err := base64.StdEncoding.DecodeString(value); err == nil && isEncodedUserData(decoded) {
+		data = decoded
+	}
+
+	if len(data) > maxUserDataSize {
+		return "", fmt.Errorf("user data is %d bytes, the maximum is %d bytes; consider compressing it with base64gzip", len(data), maxUserDataSize)
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("user data looks gzip compressed but cannot be read: %v", err)
+		}
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return "", fmt.Errorf("user data looks gzip compressed but cannot be decompressed: %v", err)
+		}
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
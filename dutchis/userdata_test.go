@@ -0,0 +1,69 @@
+package dutchis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// testGzip returns data gzip compressed.
+func testGzip(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeUserData(t *testing.T) {
+	cloudConfig := "#cloud-config\npackages:\n  - nginx\n"
+	compressed := testGzip(t, cloudConfig)
+	large := strings.Repeat("a", maxUserDataSize+1)
+	encode := base64.StdEncoding.EncodeToString
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []byte
+		wantErr string
+	}{
+		{"plain cloud-config", cloudConfig, []byte(cloudConfig), ""},
+		{"plain script", "#!/bin/sh\necho hello\n", []byte("#!/bin/sh\necho hello\n"), ""},
+		{"base64 cloud-config", encode([]byte(cloudConfig)), []byte(cloudConfig), ""},
+		{"base64 multipart", encode([]byte("Content-Type: multipart/mixed")), []byte("Content-Type: multipart/mixed"), ""},
+		{"base64gzip", encode(compressed), compressed, ""},
+		{"plain text that is valid base64", "abcd", []byte("abcd"), ""},
+		{"base64 of plain text", encode([]byte("hello world")), []byte(encode([]byte("hello world"))), ""},
+		{"empty", "", []byte{}, ""},
+		{"invalid gzip header", encode(append([]byte{0x1f, 0x8b}, "garbage"...)), nil, "cannot be read"},
+		{"truncated gzip", encode(compressed[:len(compressed)-8]), nil, "cannot be decompressed"},
+		{"too large", large, nil, "the maximum is 65536 bytes"},
+		{"too large after decoding", encode([]byte("#cloud-config\n" + large)), nil, "the maximum is 65536 bytes"},
+		{"compressed below the limit", encode(testGzip(t, "#cloud-config\n"+large)), testGzip(t, "#cloud-config\n"+large), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := encodeUserData(test.value)
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("error = %v, want it to contain %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if want := encode(test.want); got != want {
+				t.Errorf("encodeUserData() = %q, want %q", got, want)
+			}
+		})
+	}
+}
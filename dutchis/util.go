@@ -1,14 +1,23 @@
 package dutchis
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/rs/zerolog"
 )
@@ -101,7 +110,8 @@ func ConfigureLogger(enableOutput bool, logPath string, inputLogLevels map[strin
 
 	// using a multi-writer here so we can easily add additional log destination (like a json file)
 	// for now though using just the console writer because it makes pretty logs
-	consoleWriter := zerolog.ConsoleWriter{Out: f, TimeFormat: time.RFC1123Z}
+	// secrets registered with registerSensitiveValue are masked before they reach the file
+	consoleWriter := zerolog.ConsoleWriter{Out: redactingWriter{out: f}, TimeFormat: time.RFC1123Z}
 	multi := zerolog.MultiLevelWriter(consoleWriter)
 
 	// create an init logger for logging just stuff before the root logger can get going
@@ -207,6 +217,43 @@ func CreateSubLogger(loggerName string) (zerolog.Logger, error) {
 	return thisLogger, nil
 }
 
+// sensitiveValues are the secrets, like passwords of virtual servers, that are masked in
+// everything written by the loggers created by CreateSubLogger.
+var sensitiveValues = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: map[string]struct{}{}}
+
+// registerSensitiveValue masks value in all log output from now on. Register a secret before
+// logging anything that might contain it, such as API requests and errors.
+func registerSensitiveValue(value string) {
+	if value == "" {
+		return
+	}
+	sensitiveValues.Lock()
+	defer sensitiveValues.Unlock()
+	sensitiveValues.values[value] = struct{}{}
+}
+
+// redactingWriter replaces registered sensitive values in log lines before writing them out.
+type redactingWriter struct {
+	out io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	redacted := p
+	sensitiveValues.RLock()
+	for value := range sensitiveValues.values {
+		redacted = bytes.ReplaceAll(redacted, []byte(value), []byte("<redacted>"))
+	}
+	sensitiveValues.RUnlock()
+
+	if _, err := w.out.Write(redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
 func resourceDataToFlatValues(d *schema.ResourceData, resource *schema.Resource) (map[string]interface{}, error) {
 
 	flatValues := make(map[string]interface{})
@@ -304,6 +351,73 @@ func testOptionalArguments(t *testing.T, s *schema.Resource) {
 	}
 }
 
+// importTeamScopedResource is the importer of resources living in a team. It accepts either
+// the UUID of the object, or "team_uuid/object_uuid" to import an object from a team other
+// than the provider team. The resource needs a team_uuid attribute.
+func importTeamScopedResource(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	id := d.Id()
+
+	if match := rxClusterRsId.FindStringSubmatch(id); match != nil && match[0] == id {
+		if _, err := uuid.Parse(match[1]); err != nil {
+			return nil, fmt.Errorf("invalid team UUID %q in import ID %q: %v", match[1], id, err)
+		}
+		d.Set("team_uuid", match[1])
+		id = match[2]
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, fmt.Errorf("invalid import ID %q, expected <uuid> or <team_uuid>/<uuid>: %v", d.Id(), err)
+	}
+	d.SetId(id)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// sshPublicKeyTypes are the key types accepted in OpenSSH authorized_keys files.
+var sshPublicKeyTypes = []string{
+	"ssh-rsa",
+	"ssh-dss",
+	"ssh-ed25519",
+	"ecdsa-sha2-nistp256",
+	"ecdsa-sha2-nistp384",
+	"ecdsa-sha2-nistp521",
+	"sk-ssh-ed25519@openssh.com",
+	"sk-ecdsa-sha2-nistp256@openssh.com",
+}
+
+// parseOpenSSHPublicKey checks that key is a single "<type> <base64 blob> [comment]" line
+// whose blob starts with the announced key type, and returns the key without comment.
+func parseOpenSSHPublicKey(key string) (string, error) {
+	key = strings.TrimSpace(key)
+	if strings.ContainsAny(key, "\r\n") {
+		return "", fmt.Errorf("expected a single public key on one line")
+	}
+
+	fields := strings.Fields(key)
+	if len(fields) < 2 {
+		return "", fmt.Errorf("expected \"<type> <base64 key> [comment]\"")
+	}
+	keyType := fields[0]
+	if !Contains(sshPublicKeyTypes, keyType) {
+		return "", fmt.Errorf("unsupported key type %q, expected one of %s", keyType, strings.Join(sshPublicKeyTypes, ", "))
+	}
+
+	blob, err := base64.StdEncoding.DecodeString(fields[1])
+	if err != nil {
+		return "", fmt.Errorf("key is not valid base64: %v", err)
+	}
+	// the blob starts with the key type as an SSH string: a uint32 length and the bytes
+	if len(blob) < 4 {
+		return "", fmt.Errorf("key data is truncated")
+	}
+	length := binary.BigEndian.Uint32(blob)
+	if uint64(len(blob)-4) < uint64(length) || string(blob[4:4+length]) != keyType {
+		return "", fmt.Errorf("key data does not match key type %q", keyType)
+	}
+
+	return keyType + " " + fields[1], nil
+}
+
 func BoolPointer(b bool) *bool {
 	return &b
 }
@@ -317,3 +431,54 @@ func Contains(s []string, str string) bool {
 
 	return false
 }
+
+// passwordCharacterClasses are the character classes of generated passwords, each password
+// contains at least one character of every class.
+var passwordCharacterClasses = []string{
+	"abcdefghijkmnopqrstuvwxyz",
+	"ABCDEFGHJKLMNPQRSTUVWXYZ",
+	"23456789",
+	"-_.+!@#%^*=",
+}
+
+// generatePassword returns a random password of the given length using crypto/rand.
+func generatePassword(length int) (string, error) {
+	if length < len(passwordCharacterClasses) {
+		return "", fmt.Errorf("password length %d is too short", length)
+	}
+
+	randomIndex := func(n int) (int, error) {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		if err != nil {
+			return 0, err
+		}
+		return int(index.Int64()), nil
+	}
+
+	alphabet := strings.Join(passwordCharacterClasses, "")
+	password := make([]byte, 0, length)
+	for _, class := range passwordCharacterClasses {
+		index, err := randomIndex(len(class))
+		if err != nil {
+			return "", err
+		}
+		password = append(password, class[index])
+	}
+	for len(password) < length {
+		index, err := randomIndex(len(alphabet))
+		if err != nil {
+			return "", err
+		}
+		password = append(password, alphabet[index])
+	}
+
+	// shuffle so the guaranteed characters are not always at the start
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := randomIndex(i + 1)
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j] = password[j], password[i]
+	}
+	return string(password), nil
+}
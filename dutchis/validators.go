@@ -0,0 +1,162 @@
+package dutchis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+)
+
+// hostnameLabelRegex matches a single RFC 1123 label: alphanumerics and hyphens,
+// not starting or ending with a hyphen.
+var hostnameLabelRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// usernameRegex matches the usernames accepted by common Linux distributions. Uppercase is
+// allowed because Windows images ignore the username anyway.
+var usernameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_-]{0,31}$`)
+
+// minimumPasswordLength is the shortest password accepted for the default user.
+const minimumPasswordLength = 12
+
+// validationError returns a single error diagnostic for the attribute at path.
+func validationError(path cty.Path, summary string, detail string) diag.Diagnostics {
+	return diag.Diagnostics{{
+		Severity:      diag.Error,
+		Summary:       summary,
+		Detail:        detail,
+		AttributePath: path,
+	}}
+}
+
+// validateHostname is a ValidateDiagFunc for RFC 1123 hostnames.
+func validateHostname(value interface{}, path cty.Path) diag.Diagnostics {
+	hostname, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	if len(hostname) == 0 || len(hostname) > 253 {
+		return validationError(path, "Invalid hostname", fmt.Sprintf("%q must be between 1 and 253 characters long.", hostname))
+	}
+	for _, label := range strings.Split(hostname, ".") {
+		if !hostnameLabelRegex.MatchString(label) {
+			return validationError(path, "Invalid hostname",
+				fmt.Sprintf("%q is not a valid RFC 1123 hostname: every label must be 1 to 63 letters, digits or hyphens and may not start or end with a hyphen.", hostname))
+		}
+	}
+	return nil
+}
+
+// validateUsername is a ValidateDiagFunc for the name of the default user.
+func validateUsername(value interface{}, path cty.Path) diag.Diagnostics {
+	username, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	if !usernameRegex.MatchString(username) {
+		return validationError(path, "Invalid username",
+			fmt.Sprintf("%q must start with a letter or underscore, contain only letters, digits, underscores and hyphens, and be at most 32 characters long.", username))
+	}
+	return nil
+}
+
+// validatePositiveInt is a ValidateDiagFunc for sizes that must be at least one.
+func validatePositiveInt(value interface{}, path cty.Path) diag.Diagnostics {
+	number, ok := value.(int)
+	if !ok {
+		return validationError(path, "Expected a number", "")
+	}
+
+	if number < 1 {
+		return validationError(path, "Value must be positive", fmt.Sprintf("Expected a value of at least 1, got %d.", number))
+	}
+	return nil
+}
+
+// validatePassword is a ValidateDiagFunc requiring a reasonably strong password: a minimum
+// length and at least three out of lowercase, uppercase, digits and other characters.
+func validatePassword(value interface{}, path cty.Path) diag.Diagnostics {
+	password, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	// the value is sensitive, so never repeat it in the diagnostics
+	if len(password) < minimumPasswordLength {
+		return validationError(path, "Password too weak", fmt.Sprintf("The password must be at least %d characters long.", minimumPasswordLength))
+	}
+
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+	if classes < 3 {
+		return validationError(path, "Password too weak", "The password must contain at least three of: lowercase letters, uppercase letters, digits and other characters.")
+	}
+	return nil
+}
+
+// validateSSHKeyReference is a ValidateDiagFunc for entries of sshkeys, which are either the
+// UUID of a key stored in the team or an OpenSSH public key.
+func validateSSHKeyReference(value interface{}, path cty.Path) diag.Diagnostics {
+	key, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	if _, err := uuid.Parse(key); err == nil {
+		return nil
+	}
+	if _, err := parseOpenSSHPublicKey(key); err != nil {
+		return validationError(path, "Invalid SSH key",
+			fmt.Sprintf("Expected the UUID of an SSH key or an OpenSSH public key, but the value is neither: %v.", err))
+	}
+	return nil
+}
+
+// validateUserData is a ValidateDiagFunc for cloud-init user data, see encodeUserData.
+func validateUserData(value interface{}, path cty.Path) diag.Diagnostics {
+	userData, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	if _, err := encodeUserData(userData); err != nil {
+		return validationError(path, "Invalid user data", err.Error())
+	}
+	return nil
+}
+
+// validateOpenSSHPublicKey is a ValidateDiagFunc for attributes holding an OpenSSH public key.
+func validateOpenSSHPublicKey(value interface{}, path cty.Path) diag.Diagnostics {
+	key, ok := value.(string)
+	if !ok {
+		return validationError(path, "Expected a string", "")
+	}
+
+	if _, err := parseOpenSSHPublicKey(key); err != nil {
+		return validationError(path, "Invalid OpenSSH public key", err.Error())
+	}
+	return nil
+}
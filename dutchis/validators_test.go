@@ -0,0 +1,86 @@
+package dutchis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestValidators(t *testing.T) {
+	ed25519 := "ssh-ed25519 " + testPublicKeyBlob("ssh-ed25519")
+
+	tests := []struct {
+		name     string
+		validate schema.SchemaValidateDiagFunc
+		value    interface{}
+		want     string
+	}{
+		{"hostname", validateHostname, "web-1", ""},
+		{"fqdn", validateHostname, "web-1.example.com", ""},
+		{"numeric label", validateHostname, "1.example.com", ""},
+		{"empty hostname", validateHostname, "", "Invalid hostname"},
+		{"hostname with underscore", validateHostname, "web_1", "Invalid hostname"},
+		{"hostname starting with hyphen", validateHostname, "-web", "Invalid hostname"},
+		{"hostname with empty label", validateHostname, "web..example.com", "Invalid hostname"},
+		{"hostname label too long", validateHostname, strings.Repeat("a", 64), "Invalid hostname"},
+		{"hostname too long", validateHostname, strings.Repeat("a.", 127), "Invalid hostname"},
+		{"hostname not a string", validateHostname, 1, "Expected a string"},
+
+		{"username", validateUsername, "admin", ""},
+		{"username with underscore", validateUsername, "_svc-deploy", ""},
+		{"username starting with digit", validateUsername, "1admin", "Invalid username"},
+		{"username with space", validateUsername, "ad min", "Invalid username"},
+		{"username too long", validateUsername, strings.Repeat("a", 33), "Invalid username"},
+
+		{"positive", validatePositiveInt, 1, ""},
+		{"zero", validatePositiveInt, 0, "Value must be positive"},
+		{"negative", validatePositiveInt, -4, "Value must be positive"},
+		{"positive not a number", validatePositiveInt, "1", "Expected a number"},
+
+		{"password", validatePassword, "Str0ng-Passw0rd", ""},
+		{"password without digits", validatePassword, "Strong-Password", ""},
+		{"password too short", validatePassword, "Sh0rt-Pw", "Password too weak"},
+		{"password with two classes", validatePassword, "lowercase0123", "Password too weak"},
+
+		{"ssh key uuid", validateSSHKeyReference, testTeamUUID, ""},
+		{"ssh public key", validateSSHKeyReference, ed25519 + " admin@example.com", ""},
+		{"ssh key name", validateSSHKeyReference, "my-laptop", "Invalid SSH key"},
+
+		{"openssh public key", validateOpenSSHPublicKey, ed25519, ""},
+		{"openssh public key uuid", validateOpenSSHPublicKey, testTeamUUID, "Invalid OpenSSH public key"},
+
+		{"user data", validateUserData, "#cloud-config\n", ""},
+		{"user data too large", validateUserData, strings.Repeat("a", maxUserDataSize+1), "Invalid user data"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := cty.GetAttrPath("attribute").IndexInt(1)
+			diags := test.validate(test.value, path)
+			if test.want == "" {
+				if len(diags) > 0 {
+					t.Fatalf("unexpected diagnostics: %v", diags)
+				}
+				return
+			}
+			if len(diags) != 1 || diags[0].Summary != test.want {
+				t.Fatalf("diagnostics = %v, want a single %q", diags, test.want)
+			}
+			if !diags[0].AttributePath.Equals(path) {
+				t.Errorf("diagnostic path = %#v, want %#v", diags[0].AttributePath, path)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordDoesNotRevealValue(t *testing.T) {
+	for _, password := range []string{"Sh0rt-Pw", "lowercase0123"} {
+		for _, d := range validatePassword(password, nil) {
+			if strings.Contains(d.Summary, password) || strings.Contains(d.Detail, password) {
+				t.Errorf("diagnostic %q reveals the password", d.Summary+": "+d.Detail)
+			}
+		}
+	}
+}
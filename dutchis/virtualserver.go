@@ -1,13 +1,20 @@
 package dutchis
 
 import (
-	"encoding/json"
-    "io/ioutil"
-    "net/http"
-	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/customdiff"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+	"github.com/rs/zerolog"
 )
 
 // using a global variable here so that we have an internally accessible
@@ -15,76 +22,184 @@ import (
 // so that we can print (debug) our ResourceData constructs
 var thisResource *schema.Resource
 
+// generatedPasswordLength is the length of the password generated when none is configured.
+const generatedPasswordLength = 24
+
 func resourceVirtualServer() *schema.Resource {
 	thisResource = &schema.Resource{
-		Create: resourceVirtualServerCreate,
-		Read: resourceVirtualServerRead,
-		Delete: resourceVirtualServerDelete,
-		Update: resourceVirtualServerUpdate,
-
-		Schema: map[string]*schema.Schema {
+		CreateContext: resourceVirtualServerCreate,
+		ReadContext:   resourceVirtualServerRead,
+		DeleteContext: resourceVirtualServerDelete,
+		UpdateContext: resourceVirtualServerUpdate,
+		Importer: &schema.ResourceImporter{
+			StateContext: importVirtualServer,
+		},
+		CustomizeDiff: customdiff.All(
+			customizeDiffVirtualServerReinstall,
+			customizeDiffVirtualServerOS,
+			customizeDiffVirtualServerClass,
+			customizeDiffVirtualServerDisk,
+			customizeDiffVirtualServerUserData,
+		),
+
+		Schema: map[string]*schema.Schema{
+			"team_uuid": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.IsUUID,
+				Description:  "The team the virtual server belongs to, defaults to the team of the provider",
+			},
 			"hostname": {
-				Type:     schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The virtual server hostname",
+				Type:             schema.TypeString,
+				Required:         true,
+				ForceNew:         true,
+				ValidateDiagFunc: validateHostname,
+				Description:      "The virtual server hostname",
 			},
 			"class": {
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
-				Description: "The Performance class of the virtual server",
+				Description: "The Performance class of the virtual server, see the dutchis_classes data source",
 			},
 			"os": {
 				Type:        schema.TypeString,
 				Required:    true,
-				ForceNew:    true,
-				Description: "OS id of the virtual server",
+				Description: "OS id of the virtual server, see the dutchis_os_images data source. Changing it replaces the virtual server unless reinstall_on_os_change is set",
 			},
 			"username": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The username of the virtual server. This is ignored on Windows servers",
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validateUsername,
+				Description:      "The username of the virtual server. This is ignored on Windows servers",
 			},
 			"password": {
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: "The password of the default user",
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				Sensitive:        true,
+				ValidateDiagFunc: validatePassword,
+				Description:      "The password of the default user. A strong password is generated when omitted. Changing it resets the password in place",
 			},
 			"sshkeys": {
 				Type:        schema.TypeList,
 				Required:    true,
-				ForceNew:    true,
 				Description: "Provide the UUID's of ssh keys or provide a ssh key in openssh format.",
 				Elem: &schema.Schema{
-					Type: schema.TypeString,
+					Type:             schema.TypeString,
+					ValidateDiagFunc: validateSSHKeyReference,
 				},
 			},
 			"cores": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    false,
-				Description: "The amount of cores to assign to the virtual server",
+				Type:             schema.TypeInt,
+				Required:         true,
+				ForceNew:         false,
+				ValidateDiagFunc: validatePositiveInt,
+				Description:      "The amount of cores to assign to the virtual server",
 			},
 			"memory": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    false,
-				Description: "The amount of memory in GB to assign to the virtual server",
+				Type:             schema.TypeInt,
+				Required:         true,
+				ForceNew:         false,
+				ValidateDiagFunc: validatePositiveInt,
+				Description:      "The amount of memory in GB to assign to the virtual server",
 			},
 			"network": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    false,
-				Description: "The network speed in Gbps for this virtual server",
+				Type:             schema.TypeInt,
+				Required:         true,
+				ForceNew:         false,
+				ValidateDiagFunc: validatePositiveInt,
+				Description:      "The network speed in Gbps for this virtual server",
 			},
 			"disk": {
-				Type:        schema.TypeInt,
-				Required:    true,
-				ForceNew:    false,
-				Description: "The amount of storage space in GB to assign to the virtual server",
+				Type:             schema.TypeInt,
+				Required:         true,
+				ForceNew:         false,
+				ValidateDiagFunc: validatePositiveInt,
+				Description:      "The amount of storage space in GB to assign to the virtual server",
+			},
+			"user_data": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ValidateDiagFunc: validateUserData,
+				Description:      "Cloud-init user data applied on first boot, either plain text or base64 encoded and optionally gzip compressed (see base64gzip)",
+			},
+			"user_data_replace_on_change": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Replace the virtual server when user_data changes. When false a change is only recorded, as user data is applied on first boot",
+			},
+			"reinstall_on_os_change": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Reinstall the operating system in place when os changes, keeping the UUID and IP addresses. All data on the disk is lost",
+			},
+			"allow_disk_shrink_replace": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Replace the virtual server when disk is lowered instead of failing the plan. All data on the server is lost",
+			},
+			"power_state": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"running", "stopped"}, false),
+				Description:  "The desired power state of the virtual server, either running or stopped",
+			},
+			"status": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The status of the virtual server as reported by the API",
+			},
+			"node": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The node the virtual server runs on",
+			},
+			"installing": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the operating system is still being installed",
+			},
+			"ipv4_address": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The primary IPv4 address of the virtual server",
+			},
+			"ipv6_address": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The primary IPv6 address of the virtual server",
+			},
+			"ip_addresses": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "All IP addresses assigned to the virtual server",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"reboot_triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Arbitrary values that gracefully reboot the virtual server when changed",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"reset_triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				Description: "Arbitrary values that hard reset the virtual server when changed",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
 			},
 		},
 		Timeouts: resourceTimeouts(),
@@ -92,233 +207,693 @@ func resourceVirtualServer() *schema.Resource {
 	return thisResource
 }
 
-func resourceVirtualServerCreate(d *schema.ResourceData, meta interface{}) error {
+func resourceVirtualServerCreate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	providerConfig := meta.(*providerConfiguration)
-	lock := parallelBegin(providerConfig)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
 
 	logger, err := CreateSubLogger("resourceVirtualServerCreate")
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	var sshKeys []string
-	for _, sshKey := range d.Get("sshkeys").([]interface{}) {
-		sshKeys = append(sshKeys, sshKey.(string))
+	var diags diag.Diagnostics
+
+	password := d.Get("password").(string)
+	if password == "" {
+		password, err = generatePassword(generatedPasswordLength)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("password", password)
+		logger.Info().Msg("Generated a password for the default user")
 	}
+	registerSensitiveValue(password)
+
+	sshKeys := expandSSHKeys(d)
 
 	logger.Info().Msg("Parsed ssh keys from config")
 
-	type NewVirtualServer struct {
-		Hostname string `json:"hostname"`
-		Class string `json:"class"`
-		Os string `json:"os"`
-		Username string `json:"username"`
-		Password string `json:"password"`
-		Sshkeys []string `json:"sshkeys"`
-		Cores int `json:"cores"`
-		Memory int `json:"memory"`
-		Network int `json:"network"`
-		Disk int `json:"disk"`
+	if providerConfig.isWindowsOS(ctx, d.Get("os").(string)) {
+		diags = append(diags, warningDiagnostic(
+			"Username is ignored on Windows",
+			"The DutchIS platform does not create a custom user on Windows servers, the configured username will not be used.",
+			cty.GetAttrPath("username"),
+		))
 	}
 
-	newVirtualServer := NewVirtualServer{
+	userData, err := encodeUserData(d.Get("user_data").(string))
+	if err != nil {
+		return append(diags, errorDiagnostics("Invalid user data", err, cty.GetAttrPath("user_data"))...)
+	}
+
+	newVirtualServer := client.CreateVirtualServerRequest{
 		Hostname: d.Get("hostname").(string),
-		Class: d.Get("class").(string),
-		Os: d.Get("os").(string),
+		Class:    d.Get("class").(string),
+		Os:       d.Get("os").(string),
 		Username: d.Get("username").(string),
-		Password: d.Get("password").(string),
-		Sshkeys: sshKeys,
-		Cores: d.Get("cores").(int),
-		Memory: d.Get("memory").(int),
-		Network: d.Get("network").(int),
-		Disk: d.Get("disk").(int),
+		Password: password,
+		Sshkeys:  sshKeys,
+		Cores:    d.Get("cores").(int),
+		Memory:   d.Get("memory").(int),
+		Network:  d.Get("network").(int),
+		Disk:     d.Get("disk").(int),
+		UserData: userData,
 	}
 
 	logger.Info().Msg("Creating new virtual server")
 
-	body, err := json.Marshal(newVirtualServer)
+	id, err := apiClient.CreateVirtualServer(ctx, newVirtualServer)
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to marshal JSON")
-		return err
+		logger.Error().Err(err).Msg("Failed to create virtual server")
+		return append(diags, errorDiagnostics("Failed to create virtual server", err)...)
 	}
 
-	req, err := http.NewRequest("POST", "https://dutchis.net/api/v1/virtualservers", bytes.NewBuffer(body))
+	d.SetId(id)
+
+	logger.Info().Msg("Created new virtual server, waiting for it to finish provisioning")
+
+	_, err = waitForVirtualServerRunning(ctx, apiClient, id, d.Timeout(schema.TimeoutCreate))
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to create HTTP request")
-		return err
+		logger.Error().Err(err).Msg("Virtual server did not finish provisioning")
+		return append(diags, errorDiagnostics("Virtual server did not finish provisioning", err)...)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+providerConfig.APIToken)
-	req.Header.Add("X-Team-Uuid", providerConfig.TeamUUID)
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+	logger.Info().Msg("Virtual server is running")
+
+	if powerState := d.Get("power_state").(string); powerState != "" && powerState != "running" {
+		logger.Info().Msgf("Changing power state of virtual server %s to %s", id, powerState)
+		err = setVirtualServerPowerState(ctx, apiClient, id, powerState, d.Timeout(schema.TimeoutCreate))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to change power state")
+			return append(diags, errorDiagnostics("Failed to change power state of virtual server", err, cty.GetAttrPath("power_state"))...)
+		}
+	}
+
+	return append(diags, resourceVirtualServerRead(ctx, d, meta)...)
+}
+
+func resourceVirtualServerRead(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceVirtualServerRead")
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to send HTTP request")
-		return err
+		return diag.FromErr(err)
 	}
+	registerSensitiveValue(d.Get("password").(string))
 
-    defer resp.Body.Close()
-    body, err = ioutil.ReadAll(resp.Body)
+	logger.Info().Msg("Reading virtual server: " + d.Id())
+
+	virtualserver, err := apiClient.GetVirtualServer(ctx, d.Id())
+	if client.IsNotFound(err) {
+		logger.Warn().Msg("Virtual server no longer exists, removing it from state: " + d.Id())
+		diags := diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Virtual server not found",
+			Detail:   fmt.Sprintf("Virtual server %s no longer exists and has been removed from the state, it will be recreated on the next apply.", d.Id()),
+		}}
+		d.SetId("")
+		return diags
+	}
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to read HTTP response")
-		return err
+		logger.Error().Err(err).Msg("Failed to read virtual server")
+		return errorDiagnostics("Failed to read virtual server", err)
 	}
 
-	type NewVirtualServerResponse struct {
-		Success bool `json:"success"`
-		Message string `json:"message"`
-		UUID string `json:"uuid"`
+	logger.Debug().Msgf("Received virtual server: %+v", virtualserver)
+
+	d.Set("team_uuid", apiClient.TeamUUID)
+	if _, ok := powerStateActions[virtualserver.Status]; ok {
+		d.Set("power_state", virtualserver.Status)
 	}
 
-    var virtualserver NewVirtualServerResponse
-    if err := json.Unmarshal(body, &virtualserver); err != nil { 
-		logger.Error().Err(err).Msg("Failed to unmarshal JSON")
-        return err
-    }
+	diags := setVirtualServerAttributes(ctx, apiClient, d, virtualserver)
+	if len(diags) > 0 {
+		logger.Warn().Msg("Unable to read IP addresses of virtual server: " + d.Id())
+	}
 
-    d.SetId(virtualserver.UUID)
+	logger.Info().Msg("Read configuration for virtual server: " + d.Id())
 
-	logger.Info().Msg("Created new virtual server")
-	time.Sleep(3 * time.Second)
-	lock.unlock()
-	return resourceVirtualServerRead(d, meta)
+	return diags
 }
 
-func resourceVirtualServerRead(d *schema.ResourceData, meta interface{}) error {
-	pconf := meta.(*providerConfiguration)
-	lock := parallelBegin(pconf)
-	defer lock.unlock()
+func resourceVirtualServerDelete(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
 	providerConfig := meta.(*providerConfiguration)
-	
-	logger, err := CreateSubLogger("resourceVirtualServerRead")
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceVirtualServerDelete")
 	if err != nil {
-		return err
+		return diag.FromErr(err)
 	}
 
-	logger.Info().Msg("Reading virtual server: " + d.Id())
-	req, err := http.NewRequest("GET", "https://dutchis.net/api/v1/virtualservers/" + d.Id(), nil)
+	logger.Info().Msg("Deleting virtual server: " + d.Id())
+
+	err = apiClient.DeleteVirtualServer(ctx, d.Id())
+	if client.IsNotFound(err) {
+		logger.Info().Msg("Virtual server was already deleted: " + d.Id())
+		return nil
+	}
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to create HTTP request")
-		return err
+		logger.Error().Err(err).Msg("Failed to delete virtual server")
+		return errorDiagnostics("Failed to delete virtual server", err)
+	}
+
+	err = waitForVirtualServerDeleted(ctx, apiClient, d.Id(), d.Timeout(schema.TimeoutDelete))
+	if err != nil {
+		logger.Error().Err(err).Msg("Virtual server was not deleted in time")
+		return errorDiagnostics("Virtual server was not deleted in time", err)
+	}
+
+	logger.Info().Msg("Deleted virtual server: " + d.Id())
+	return nil
+}
+
+func resourceVirtualServerUpdate(ctx context.Context, d *schema.ResourceData, meta interface{}) diag.Diagnostics {
+	providerConfig := meta.(*providerConfiguration)
+	apiClient := providerConfig.clientForTeam(d.Get("team_uuid").(string))
+
+	logger, err := CreateSubLogger("resourceVirtualServerUpdate")
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	registerSensitiveValue(d.Get("password").(string))
+
+	// keep the previous state if any step fails, so values that were never
+	// applied are not recorded
+	d.Partial(true)
+
+	var diags diag.Diagnostics
+
+	adopted := virtualServerAdopted(d)
+	reinstall := virtualServerReinstalls(d)
+
+	if adopted && d.HasChange("password") {
+		diags = append(diags, warningDiagnostic(
+			"Password recorded without reset",
+			"The password of an imported virtual server cannot be read, so the configured password is recorded without changing the password on the server. Change it again to reset it.",
+			cty.GetAttrPath("password"),
+		))
+	}
+
+	if d.HasChanges("cores", "memory", "network", "disk") {
+		var specs client.UpdateVirtualServerSpecsRequest
+		var paths []cty.Path
+		if d.HasChange("cores") {
+			specs.Cores = d.Get("cores").(int)
+			paths = append(paths, cty.GetAttrPath("cores"))
+		}
+		if d.HasChange("memory") {
+			specs.Memory = d.Get("memory").(int)
+			paths = append(paths, cty.GetAttrPath("memory"))
+		}
+		if d.HasChange("network") {
+			specs.Network = d.Get("network").(int)
+			paths = append(paths, cty.GetAttrPath("network"))
+		}
+		if d.HasChange("disk") {
+			specs.Disk = d.Get("disk").(int)
+			paths = append(paths, cty.GetAttrPath("disk"))
+		}
+
+		logger.Info().Msgf("Resizing virtual server %s: %+v", d.Id(), specs)
+
+		err = apiClient.UpdateVirtualServerSpecs(ctx, d.Id(), specs)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to resize virtual server")
+			return append(diags, errorDiagnostics("Failed to resize virtual server", err, paths...)...)
+		}
+
+		_, err = waitForVirtualServerSpecs(ctx, apiClient, d.Id(), specs, d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			logger.Error().Err(err).Msg("Virtual server did not apply the new specs")
+			return append(diags, errorDiagnostics("Virtual server did not apply the new specs", err, paths...)...)
+		}
+
+		logger.Info().Msg("Resized virtual server: " + d.Id())
+	}
+
+	// a reinstall sets the new password itself
+	if password := d.Get("password").(string); !adopted && !reinstall && d.HasChange("password") && password != "" {
+		registerSensitiveValue(password)
+		logger.Info().Msg("Resetting password of virtual server: " + d.Id())
+
+		err = apiClient.ResetVirtualServerPassword(ctx, d.Id(), password)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to reset password")
+			return append(diags, errorDiagnostics("Failed to reset password of virtual server", err, cty.GetAttrPath("password"))...)
+		}
+
+		_, err = waitForVirtualServerIdle(ctx, apiClient, d.Id(), d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			logger.Error().Err(err).Msg("Virtual server did not finish the password reset")
+			return append(diags, errorDiagnostics("Virtual server did not finish the password reset", err, cty.GetAttrPath("password"))...)
+		}
+
+		logger.Info().Msg("Reset password of virtual server: " + d.Id())
+	}
+
+	if powerState := d.Get("power_state").(string); d.HasChange("power_state") && powerState != "" {
+		logger.Info().Msgf("Changing power state of virtual server %s to %s", d.Id(), powerState)
+		err = setVirtualServerPowerState(ctx, apiClient, d.Id(), powerState, d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to change power state")
+			return append(diags, errorDiagnostics("Failed to change power state of virtual server", err, cty.GetAttrPath("power_state"))...)
+		}
+	}
+
+	if !adopted && !reinstall && d.HasChange("user_data") {
+		// only reachable with user_data_replace_on_change = false
+		diags = append(diags, warningDiagnostic(
+			"User data not applied",
+			"User data is only applied on the first boot of a virtual server. The new value is recorded but does not affect the running server.",
+			cty.GetAttrPath("user_data"),
+		))
+	}
+
+	// a reinstall boots the server anyway
+	if !reinstall && d.HasChanges("reboot_triggers", "reset_triggers") {
+		action, path := client.PowerActionReboot, cty.GetAttrPath("reboot_triggers")
+		if d.HasChange("reset_triggers") {
+			action, path = client.PowerActionReset, cty.GetAttrPath("reset_triggers")
+		}
+
+		if d.HasChange("power_state") || d.Get("power_state").(string) == "stopped" {
+			// the server was just started or stopped, or is meant to stay off
+			logger.Info().Msgf("Skipping %s of virtual server %s because of its power state", action, d.Id())
+			diags = append(diags, warningDiagnostic(
+				"Virtual server not restarted",
+				fmt.Sprintf("The %s was skipped because the power state of the virtual server changed in the same apply or it is stopped.", action),
+				path,
+			))
+		} else {
+			logger.Info().Msgf("Performing %s of virtual server %s", action, d.Id())
+			observed, err := restartVirtualServer(ctx, apiClient, d.Id(), action, d.Timeout(schema.TimeoutUpdate))
+			if err != nil {
+				logger.Error().Err(err).Msgf("Failed to %s virtual server", action)
+				return append(diags, errorDiagnostics(fmt.Sprintf("Failed to %s virtual server", action), err, path)...)
+			}
+			if !observed {
+				logger.Warn().Msgf("Virtual server %s kept reporting running after the %s", d.Id(), action)
+				diags = append(diags, warningDiagnostic(
+					"Virtual server restart not observed",
+					fmt.Sprintf("The API accepted the %s, but the virtual server was never seen leaving the running status. It either restarted between two status checks or the %s was not performed.", action, action),
+					path,
+				))
+			}
+		}
+	}
+
+	// reinstalling comes last: once the API accepted it the disk is wiped, so the new
+	// values are recorded even if waiting fails, otherwise the next apply reinstalls again
+	if reinstall {
+		reinstallDiags := reinstallVirtualServer(ctx, apiClient, d, logger)
+		if reinstallDiags.HasError() {
+			return append(diags, reinstallDiags...)
+		}
+		diags = append(diags, reinstallDiags...)
+	}
+
+	d.Partial(false)
+
+	return append(diags, resourceVirtualServerRead(ctx, d, meta)...)
+}
+
+// customizeDiffVirtualServerOS checks the os against the catalogue during plan. When the
+// catalogue cannot be fetched the check is skipped and left to the API. The os of an
+// imported server is only recorded, so it may be an image that is no longer offered.
+func customizeDiffVirtualServerOS(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	providerConfig, ok := meta.(*providerConfiguration)
+	if !ok || !d.HasChange("os") || !d.NewValueKnown("os") || virtualServerAdopted(d) {
+		return nil
 	}
-	req.Header.Add("Authorization", "Bearer "+providerConfig.APIToken)
-	req.Header.Add("X-Team-Uuid", providerConfig.TeamUUID)
-	req.Header.Add("Content-Type", "application/json")
-	resp, err := http.DefaultClient.Do(req)
+
+	logger, err := CreateSubLogger("customizeDiffVirtualServer")
 	if err != nil {
 		return err
 	}
-	
-    defer resp.Body.Close()
-    body, err := ioutil.ReadAll(resp.Body)
+
+	images, err := providerConfig.osImages(ctx)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Unable to fetch the operating system catalogue, skipping os validation")
+		return nil
+	}
+
+	osID := d.Get("os").(string)
+	ids := make([]string, 0, len(images))
+	for _, image := range images {
+		if image.ID == osID {
+			return nil
+		}
+		ids = append(ids, image.ID)
+	}
+	return fmt.Errorf("os %q is not available, expected one of: %s", osID, strings.Join(ids, ", "))
+}
+
+// importVirtualServer imports a virtual server like importTeamScopedResource and sets the
+// defaults of the arguments that only live in the configuration, so the first plan after
+// an import is empty.
+func importVirtualServer(ctx context.Context, d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	d.Set("allow_disk_shrink_replace", false)
+	d.Set("user_data_replace_on_change", true)
+	d.Set("reinstall_on_os_change", false)
+	return importTeamScopedResource(ctx, d, meta)
+}
+
+// customizeDiffVirtualServerDisk refuses to shrink the disk of an existing virtual server,
+// which the platform cannot do in place. With allow_disk_shrink_replace the server is
+// replaced instead.
+func customizeDiffVirtualServerDisk(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("disk") || !d.NewValueKnown("disk") {
+		return nil
+	}
+
+	oldDisk, newDisk := d.GetChange("disk")
+	if newDisk.(int) >= oldDisk.(int) {
+		return nil
+	}
+
+	if !d.Get("allow_disk_shrink_replace").(bool) {
+		return fmt.Errorf("disk cannot be shrunk from %d GB to %d GB in place: disks can only grow. "+
+			"Set allow_disk_shrink_replace = true to replace the virtual server instead, which destroys all data on it",
+			oldDisk.(int), newDisk.(int))
+	}
+
+	logger, err := CreateSubLogger("customizeDiffVirtualServer")
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to read HTTP response")
 		return err
 	}
+	logger.Warn().Str("uuid", d.Id()).Int("old_disk", oldDisk.(int)).Int("new_disk", newDisk.(int)).
+		Msg("Disk shrink requested with allow_disk_shrink_replace, the virtual server will be replaced")
 
-	logger.Debug().Msg("Received response: " + string(body))
-	
-	type VirtualServer struct {
-		Success bool `json:"success"`
-		Data    struct {
-			UUID       string `json:"uuid"`
-			Name       string `json:"name"`
-			Class      string `json:"class"`
-			Status     string `json:"status"`
-			Node       string `json:"node"`
-			Cpus       int    `json:"cpus"`
-			Maxmem     int    `json:"maxmem"`
-			Maxdisk    int    `json:"maxdisk"`
-			Installing bool   `json:"installing,omitempty"`
-		} `json:"data"`	
-	}
-
-    var virtualserver VirtualServer
-    if err := json.Unmarshal(body, &virtualserver); err != nil { 
-		logger.Error().Err(err).Msg("Failed to unmarshal JSON")
-        return err
-    }
-
-	d.Set("hostname", virtualserver.Data.Name)
-	d.Set("class", virtualserver.Data.Class)
-	d.Set("cores", virtualserver.Data.Cpus)
-	d.Set("memory", virtualserver.Data.Maxmem)
-	d.Set("disk", virtualserver.Data.Maxdisk)
+	return d.ForceNew("disk")
+}
 
-	logger.Info().Msg("Read configuration for virtual server: " + d.Id())
+// virtualServerAdopted reports whether the virtual server was imported and this is its first
+// change since. Read cannot recover the arguments that are only used during installation,
+// so their configured values are recorded without replacing, reinstalling or resetting
+// anything. Servers created by Terraform always have an os in state, an empty one marks an
+// imported server.
+func virtualServerAdopted(d interface {
+	Id() string
+	GetChange(string) (interface{}, interface{})
+}) bool {
+	oldOS, _ := d.GetChange("os")
+	return d.Id() != "" && oldOS.(string) == ""
+}
+
+// virtualServerReinstalls reports whether the pending change reinstalls the operating system
+// in place, which only happens when os changes and reinstall_on_os_change is set. The os of
+// an imported server is recorded rather than installed, see virtualServerAdopted.
+func virtualServerReinstalls(d interface {
+	HasChange(string) bool
+	Get(string) interface{}
+	GetChange(string) (interface{}, interface{})
+	Id() string
+}) bool {
+	return d.HasChange("os") && !virtualServerAdopted(d) && d.Get("reinstall_on_os_change").(bool)
+}
+
+// customizeDiffVirtualServerReinstall replaces the virtual server when the arguments that
+// are only used during installation change. When the server is reinstalled in place they
+// are sent along with the new os instead.
+func customizeDiffVirtualServerReinstall(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || virtualServerAdopted(d) || virtualServerReinstalls(d) {
+		return nil
+	}
 
+	for _, key := range []string{"os", "username", "sshkeys"} {
+		if d.HasChange(key) {
+			if err := d.ForceNew(key); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func resourceVirtualServerDelete(d *schema.ResourceData, meta interface{}) error {
-	providerConfig := meta.(*providerConfiguration)
-	lock := parallelBegin(providerConfig)
-	defer lock.unlock()
-	
-	logger, err := CreateSubLogger("resourceVirtualServerDelete")
+// customizeDiffVirtualServerUserData replaces the virtual server when user_data changes,
+// unless user_data_replace_on_change is turned off. User data cannot be changed through the
+// API, it only takes effect on the first boot.
+func customizeDiffVirtualServerUserData(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.HasChange("user_data") || !d.Get("user_data_replace_on_change").(bool) ||
+		virtualServerAdopted(d) || virtualServerReinstalls(d) {
+		return nil
+	}
+	return d.ForceNew("user_data")
+}
+
+// customizeDiffVirtualServerClass checks the class and the requested sizes against the
+// limits of the class during plan. Like the os check it is skipped when the classes
+// cannot be fetched.
+func customizeDiffVirtualServerClass(ctx context.Context, d *schema.ResourceDiff, meta interface{}) error {
+	providerConfig, ok := meta.(*providerConfiguration)
+	if !ok || !d.HasChanges("class", "cores", "memory", "disk", "network") {
+		return nil
+	}
+	for _, key := range []string{"class", "cores", "memory", "disk", "network"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+
+	logger, err := CreateSubLogger("customizeDiffVirtualServer")
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("DELETE", "https://dutchis.net/api/v1/virtualservers/" + d.Id(), nil)
+	classes, err := providerConfig.classes(ctx)
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to create HTTP request")
-		return err
+		logger.Warn().Err(err).Msg("Unable to fetch the performance classes, skipping class validation")
+		return nil
 	}
-	req.Header.Add("Authorization", "Bearer "+providerConfig.APIToken)
-	req.Header.Add("X-Team-Uuid", providerConfig.TeamUUID)
-	_, err = http.DefaultClient.Do(req)
 
-	return err
+	classID := d.Get("class").(string)
+	ids := make([]string, 0, len(classes))
+	for _, class := range classes {
+		ids = append(ids, class.ID)
+		if class.ID != classID {
+			continue
+		}
+
+		if d.Id() == "" && !class.Available {
+			return fmt.Errorf("class %q is not available for new virtual servers", classID)
+		}
+
+		var errs []string
+		for _, limit := range []struct {
+			key      string
+			min, max int
+		}{
+			{"cores", class.MinCores, class.MaxCores},
+			{"memory", class.MinMemory, class.MaxMemory},
+			{"disk", class.MinDisk, class.MaxDisk},
+			{"network", class.MinNetwork, class.MaxNetwork},
+		} {
+			// a zero maximum means the API does not limit this value
+			value := d.Get(limit.key).(int)
+			switch {
+			case limit.max > 0 && (value < limit.min || value > limit.max):
+				errs = append(errs, fmt.Sprintf("%s must be between %d and %d in class %q, got %d", limit.key, limit.min, limit.max, classID, value))
+			case value < limit.min:
+				errs = append(errs, fmt.Sprintf("%s must be at least %d in class %q, got %d", limit.key, limit.min, classID, value))
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%s", strings.Join(errs, "; "))
+		}
+		return nil
+	}
+	return fmt.Errorf("class %q does not exist, expected one of: %s", classID, strings.Join(ids, ", "))
 }
 
-func resourceVirtualServerUpdate(d *schema.ResourceData, meta interface{}) error {
-	providerConfig := meta.(*providerConfiguration)
-	lock := parallelBegin(providerConfig)
+// setVirtualServerAttributes stores the attributes the resource and the data sources share,
+// fetching the IP addresses of the server on the way.
+func setVirtualServerAttributes(ctx context.Context, apiClient *client.Client, d *schema.ResourceData, virtualserver *client.VirtualServer) diag.Diagnostics {
+	for key, value := range flattenVirtualServer(virtualserver) {
+		d.Set(key, value)
+	}
 
-	logger, err := CreateSubLogger("resourceVirtualServerUpdate")
+	// the IP addresses are not essential to reading the server, so like the catalogue
+	// lookups a failure only leaves them unchanged
+	attributes, err := virtualServerIPAttributes(ctx, apiClient, virtualserver.UUID)
 	if err != nil {
-		return err
+		return diag.Diagnostics{{
+			Severity: diag.Warning,
+			Summary:  "Unable to read IP addresses of virtual server",
+			Detail:   fmt.Sprintf("The IP addresses of virtual server %s could not be read and are left unchanged: %v", virtualserver.UUID, err),
+		}}
+	}
+	for key, value := range attributes {
+		d.Set(key, value)
+	}
+	return nil
+}
+
+// flattenVirtualServer returns the shared attributes of a virtual server, keyed by attribute name.
+func flattenVirtualServer(virtualserver *client.VirtualServer) map[string]interface{} {
+	return map[string]interface{}{
+		"hostname":   virtualserver.Name,
+		"class":      virtualserver.Class,
+		"cores":      virtualserver.Cpus,
+		"memory":     virtualserver.Maxmem,
+		"disk":       virtualserver.Maxdisk,
+		"status":     virtualserver.Status,
+		"node":       virtualserver.Node,
+		"installing": virtualserver.Installing,
+	}
+}
+
+// virtualServerIPAttributes fetches the IP addresses of a virtual server and returns the
+// ipv4_address, ipv6_address and ip_addresses attributes.
+func virtualServerIPAttributes(ctx context.Context, apiClient *client.Client, uuid string) (map[string]interface{}, error) {
+	ips, err := apiClient.ListVirtualServerIPs(ctx, uuid)
+	if err != nil {
+		return nil, err
 	}
+	ipv4, ipv6, addresses := flattenIPAddresses(ips)
 
-	type UpdateVirtualServer struct {
-		Cores int `json:"cores"`
-		Memory int `json:"memory"`
-		Network int `json:"network"`
-		Disk int `json:"disk"`
+	return map[string]interface{}{
+		"ipv4_address": ipv4,
+		"ipv6_address": ipv6,
+		"ip_addresses": addresses,
+	}, nil
+}
+
+// flattenIPAddresses returns the primary IPv4 and IPv6 address, falling back to the first
+// address of each version, along with all addresses.
+func flattenIPAddresses(ips []client.IPAddress) (string, string, []string) {
+	var ipv4, ipv6 string
+	addresses := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		addresses = append(addresses, ip.Address)
+		switch {
+		case ip.Version == 4 && (ipv4 == "" || ip.Primary):
+			ipv4 = ip.Address
+		case ip.Version == 6 && (ipv6 == "" || ip.Primary):
+			ipv6 = ip.Address
+		}
 	}
-	
-	updateVirtualServer := UpdateVirtualServer{
-		Cores: d.Get("cores").(int),
-		Memory: d.Get("memory").(int),
-		Network: d.Get("network").(int),
-		Disk: d.Get("disk").(int),
+	return ipv4, ipv6, addresses
+}
+
+// powerStateActions maps every supported power_state to the power action reaching it.
+var powerStateActions = map[string]string{
+	"running": client.PowerActionStart,
+	"stopped": client.PowerActionShutdown,
+}
+
+// reinstallVirtualServer reinstalls the operating system with the configured os, username,
+// password, sshkeys and user_data, and waits until the installation is done. It turns off
+// partial state as soon as the API accepted the reinstall.
+func reinstallVirtualServer(ctx context.Context, apiClient *client.Client, d *schema.ResourceData, logger zerolog.Logger) diag.Diagnostics {
+	password := d.Get("password").(string)
+	if password == "" {
+		var err error
+		password, err = generatePassword(generatedPasswordLength)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		d.Set("password", password)
 	}
-	
-	logger.Info().Msg("Deleting virtual server")
+	registerSensitiveValue(password)
 
-	body, err := json.Marshal(updateVirtualServer)
+	userData, err := encodeUserData(d.Get("user_data").(string))
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to marshal JSON")
-		return err
+		return errorDiagnostics("Invalid user data", err, cty.GetAttrPath("user_data"))
 	}
 
-	req, err := http.NewRequest("PATCH", "https://dutchis.net/api/v1/virtualservers/" + d.Id() + "/specs", bytes.NewBuffer(body))
+	reinstall := client.ReinstallVirtualServerRequest{
+		Os:       d.Get("os").(string),
+		Username: d.Get("username").(string),
+		Password: password,
+		Sshkeys:  expandSSHKeys(d),
+		UserData: userData,
+	}
+
+	logger.Info().Msgf("Reinstalling virtual server %s with %s", d.Id(), reinstall.Os)
+
+	err = apiClient.ReinstallVirtualServer(ctx, d.Id(), reinstall)
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to create HTTP request")
-		return err
+		logger.Error().Err(err).Msg("Failed to reinstall virtual server")
+		return errorDiagnostics("Failed to reinstall virtual server", err, cty.GetAttrPath("os"))
 	}
-	req.Header.Add("Authorization", "Bearer "+providerConfig.APIToken)
-	req.Header.Add("X-Team-Uuid", providerConfig.TeamUUID)
-	_, err = http.DefaultClient.Do(req)
+	d.Partial(false)
+
+	_, err = waitForVirtualServerRunning(ctx, apiClient, d.Id(), d.Timeout(schema.TimeoutUpdate))
 	if err != nil {
-		logger.Error().Err(err).Msg("Failed to send HTTP request")
+		logger.Error().Err(err).Msg("Virtual server did not finish reinstalling")
+		return errorDiagnostics("Virtual server did not finish reinstalling", err, cty.GetAttrPath("os"))
+	}
+
+	logger.Info().Msg("Reinstalled virtual server: " + d.Id())
+
+	// a reinstalled server boots, so a stopped server has to be stopped again
+	if d.Get("power_state").(string) == "stopped" {
+		err = setVirtualServerPowerState(ctx, apiClient, d.Id(), "stopped", d.Timeout(schema.TimeoutUpdate))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to change power state")
+			return errorDiagnostics("Failed to change power state of virtual server", err, cty.GetAttrPath("power_state"))
+		}
+	}
+	return nil
+}
+
+// expandSSHKeys returns the sshkeys argument as sent to the API.
+func expandSSHKeys(d *schema.ResourceData) []string {
+	var sshKeys []string
+	for _, sshKey := range d.Get("sshkeys").([]interface{}) {
+		sshKeys = append(sshKeys, sshKey.(string))
+	}
+	return sshKeys
+}
+
+// setVirtualServerPowerState moves the virtual server to the given power_state and
+// waits until the transition is complete.
+func setVirtualServerPowerState(ctx context.Context, apiClient *client.Client, uuid string, powerState string, timeout time.Duration) error {
+	virtualserver, err := apiClient.GetVirtualServer(ctx, uuid)
+	if err != nil {
+		return err
+	}
+	if virtualserver.Status == powerState {
+		return nil
+	}
+
+	if err := apiClient.PowerVirtualServer(ctx, uuid, powerStateActions[powerState]); err != nil {
 		return err
 	}
 
-    d.SetId("")
+	_, err = waitForVirtualServerStatus(ctx, apiClient, uuid, powerState, timeout)
+	return err
+}
+
+// restartStartTimeout bounds the wait for a reboot or reset to take the virtual server out
+// of the running status. The API reports neither power tasks nor uptime, so a restart that
+// completes between two polls cannot be told apart from one that never happened; after
+// this period the restart is assumed to have happened and the caller warns about it.
+var restartStartTimeout = 15 * time.Second
+
+// restartVirtualServer reboots or resets the virtual server and waits until it is running
+// again. It reports whether the server was seen leaving the running status.
+func restartVirtualServer(ctx context.Context, apiClient *client.Client, uuid string, action string, timeout time.Duration) (bool, error) {
+	if err := apiClient.PowerVirtualServer(ctx, uuid, action); err != nil {
+		return false, err
+	}
+
+	// the server usually still reports running right after the request, so wait for the
+	// restart to begin before waiting for the server to be running again
+	startTimeout := restartStartTimeout
+	if timeout < startTimeout {
+		startTimeout = timeout
+	}
+	_, err := waitForVirtualServer(ctx, apiClient, uuid, startTimeout, "did not begin to "+action, func(virtualserver *client.VirtualServer) bool {
+		return virtualserver.Status != "running"
+	})
+	var timeoutErr *retry.TimeoutError
+	if err != nil && !errors.As(err, &timeoutErr) {
+		return false, err
+	}
+	observed := err == nil
 
-	logger.Info().Msg("Deleted virtual server")
-	lock.unlock()
-	return resourceVirtualServerRead(d, meta)
+	_, err = waitForVirtualServerRunning(ctx, apiClient, uuid, timeout)
+	return observed, err
 }
@@ -0,0 +1,520 @@
+package dutchis
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+// testVirtualServerConfig returns a valid dutchis_virtualserver configuration with overrides applied.
+func testVirtualServerConfig(overrides map[string]interface{}) map[string]interface{} {
+	config := map[string]interface{}{
+		"hostname": "web-1",
+		"class":    "performance",
+		"os":       "ubuntu2204",
+		"username": "admin",
+		"password": "Str0ng-Passw0rd",
+		"sshkeys":  []interface{}{},
+		"cores":    2,
+		"memory":   4,
+		"network":  1,
+		"disk":     50,
+	}
+	for key, value := range overrides {
+		config[key] = value
+	}
+	return config
+}
+
+// recordingHandler counts the requests passing through to the fake API and can fail
+// selected ones.
+type recordingHandler struct {
+	next http.Handler
+
+	mu       sync.Mutex
+	requests []string
+	fail     func(r *http.Request, requests []string) int
+}
+
+func (h *recordingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests = append(h.requests, r.Method+" "+r.URL.Path)
+	status := 0
+	if h.fail != nil {
+		status = h.fail(r, h.requests)
+	}
+	h.mu.Unlock()
+
+	if status != 0 {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"success": false, "message": "injected failure"}`))
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+func (h *recordingHandler) count(method string, suffix string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	count := 0
+	for _, request := range h.requests {
+		if strings.HasPrefix(request, method+" ") && strings.HasSuffix(request, suffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// mustApply is testApply failing the test on errors.
+func mustApply(t *testing.T, provider *schema.Provider, state *terraform.InstanceState, config map[string]interface{}) *terraform.InstanceState {
+	t.Helper()
+	state, diags := testApply(t, provider, "dutchis_virtualserver", state, config)
+	if diags.HasError() {
+		t.Fatalf("apply failed: %v", diags)
+	}
+	return state
+}
+
+func TestResourceVirtualServerLifecycle(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+	id := state.ID
+	for key, want := range map[string]string{
+		"team_uuid":    testTeamUUID,
+		"hostname":     "web-1",
+		"status":       "running",
+		"power_state":  "running",
+		"installing":   "false",
+		"ipv4_address": "192.0.2.2",
+		"cores":        "2",
+	} {
+		if got := state.Attributes[key]; got != want {
+			t.Errorf("after create %s = %q, want %q", key, got, want)
+		}
+	}
+
+	state = mustApply(t, provider, state, testVirtualServerConfig(map[string]interface{}{
+		"cores":       4,
+		"disk":        80,
+		"power_state": "stopped",
+	}))
+	if state.ID != id {
+		t.Fatalf("update replaced the virtual server: %s -> %s", id, state.ID)
+	}
+	for key, want := range map[string]string{
+		"cores":       "4",
+		"disk":        "80",
+		"status":      "stopped",
+		"power_state": "stopped",
+	} {
+		if got := state.Attributes[key]; got != want {
+			t.Errorf("after update %s = %q, want %q", key, got, want)
+		}
+	}
+
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	if _, diags := resource.Apply(context.Background(), state, &terraform.InstanceDiff{Destroy: true}, provider.Meta()); diags.HasError() {
+		t.Fatalf("destroy failed: %v", diags)
+	}
+	_, err := provider.Meta().(*providerConfiguration).Client.GetVirtualServer(context.Background(), id)
+	if !client.IsNotFound(err) {
+		t.Fatalf("virtual server still exists after destroy: %v", err)
+	}
+}
+
+func TestResourceVirtualServerPasswordRotation(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+	id := state.ID
+
+	state = mustApply(t, provider, state, testVirtualServerConfig(map[string]interface{}{
+		"password": "An0ther-Passw0rd",
+	}))
+	if state.ID != id {
+		t.Fatalf("changing the password replaced the virtual server: %s -> %s", id, state.ID)
+	}
+	if got := handler.count(http.MethodPost, "/resetpassword"); got != 1 {
+		t.Errorf("%d password reset requests, want 1", got)
+	}
+	if got := state.Attributes["password"]; got != "An0ther-Passw0rd" {
+		t.Errorf("password after rotation = %q, want the new password", got)
+	}
+	if got := state.Attributes["status"]; got != "running" {
+		t.Errorf("status after rotation = %q, want running", got)
+	}
+}
+
+func TestResourceVirtualServerGeneratedPassword(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+
+	config := testVirtualServerConfig(nil)
+	delete(config, "password")
+	state := mustApply(t, provider, nil, config)
+
+	password := state.Attributes["password"]
+	if diags := validatePassword(password, nil); len(diags) > 0 {
+		t.Fatalf("generated password %q is not accepted: %v", password, diags)
+	}
+	if plan := testPlan(t, provider, "dutchis_virtualserver", state, config); !plan.Empty() {
+		t.Fatalf("plan after create is not empty: %v", plan)
+	}
+}
+
+func TestResourceVirtualServerRebootTriggers(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(map[string]interface{}{
+		"reboot_triggers": map[string]interface{}{"config": "1"},
+	}))
+	state, diags := testApply(t, provider, "dutchis_virtualserver", state, testVirtualServerConfig(map[string]interface{}{
+		"reboot_triggers": map[string]interface{}{"config": "2"},
+	}))
+	if len(diags) > 0 {
+		t.Fatalf("reboot returned diagnostics: %v", diags)
+	}
+
+	if got := handler.count(http.MethodPost, "/power"); got != 1 {
+		t.Errorf("%d power requests, want 1", got)
+	}
+	if got := state.Attributes["status"]; got != "running" {
+		t.Errorf("status after reboot = %q, want running", got)
+	}
+}
+
+func TestResourceVirtualServerRebootNotObserved(t *testing.T) {
+	fake := newTestFakeAPI()
+	// the reboot completes before the first poll, so the server never reports rebooting
+	fake.PowerDuration = 0
+	provider := newTestProvider(t, fake)
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(map[string]interface{}{
+		"reset_triggers": map[string]interface{}{"config": "1"},
+	}))
+	start := time.Now()
+	state, diags := testApply(t, provider, "dutchis_virtualserver", state, testVirtualServerConfig(map[string]interface{}{
+		"reset_triggers": map[string]interface{}{"config": "2"},
+	}))
+	if diags.HasError() || len(diags) != 1 || diags[0].Summary != "Virtual server restart not observed" {
+		t.Fatalf("expected a single warning about the unobserved reset, got %v", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 10*restartStartTimeout {
+		t.Errorf("the unobserved reset took %s", elapsed)
+	}
+	if got := state.Attributes["reset_triggers.config"]; got != "2" {
+		t.Errorf("reset_triggers.config = %q, want the new value", got)
+	}
+}
+
+func TestResourceVirtualServerDiskShrink(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+
+	_, diags := testApply(t, provider, "dutchis_virtualserver", state, testVirtualServerConfig(map[string]interface{}{"disk": 40}))
+	if !diags.HasError() || !strings.Contains(diags[0].Summary, "disk cannot be shrunk") {
+		t.Fatalf("shrinking the disk did not fail the plan: %v", diags)
+	}
+
+	plan := testPlan(t, provider, "dutchis_virtualserver", state, testVirtualServerConfig(map[string]interface{}{
+		"disk":                      40,
+		"allow_disk_shrink_replace": true,
+	}))
+	if !plan.RequiresNew() {
+		t.Fatal("shrinking the disk with allow_disk_shrink_replace does not replace the virtual server")
+	}
+}
+
+func TestResourceVirtualServerImport(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	ctx := context.Background()
+
+	created := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+
+	data := resource.Data(&terraform.InstanceState{ID: testTeamUUID + "/" + created.ID})
+	imported, err := resource.Importer.StateContext(ctx, data, provider.Meta())
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if diags := resource.ReadContext(ctx, imported[0], provider.Meta()); diags.HasError() {
+		t.Fatalf("read after import failed: %v", diags)
+	}
+	state := imported[0].State()
+	if state.ID != created.ID || state.Attributes["hostname"] != "web-1" {
+		t.Fatalf("imported state does not match the virtual server: %v", state.Attributes)
+	}
+
+	config := testVirtualServerConfig(map[string]interface{}{
+		"user_data":              "#cloud-config\n",
+		"reinstall_on_os_change": true,
+	})
+	if plan := testPlan(t, provider, "dutchis_virtualserver", state, config); plan.RequiresNew() {
+		t.Fatalf("first plan after import replaces the virtual server: %v", plan)
+	}
+
+	state, diags := testApply(t, provider, "dutchis_virtualserver", state, config)
+	if diags.HasError() {
+		t.Fatalf("apply after import failed: %v", diags)
+	}
+	if state.ID != created.ID || state.Attributes["os"] != "ubuntu2204" {
+		t.Fatalf("install-only arguments were not recorded: %v", state.Attributes)
+	}
+	if got := handler.count(http.MethodPost, "/reinstall") + handler.count(http.MethodPost, "/resetpassword"); got != 0 {
+		t.Errorf("adopting the imported virtual server sent %d reinstall or password requests", got)
+	}
+	if len(diags) != 1 || diags[0].Summary != "Password recorded without reset" {
+		t.Errorf("expected a warning about the recorded password, got %v", diags)
+	}
+
+	if plan := testPlan(t, provider, "dutchis_virtualserver", state, config); !plan.Empty() {
+		t.Fatalf("plan after adopting is not empty: %v", plan)
+	}
+}
+
+func TestResourceVirtualServerReinstall(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+
+	config := testVirtualServerConfig(map[string]interface{}{"reinstall_on_os_change": true})
+	state := mustApply(t, provider, nil, config)
+	id, ip := state.ID, state.Attributes["ipv4_address"]
+
+	config = testVirtualServerConfig(map[string]interface{}{
+		"reinstall_on_os_change": true,
+		"os":                     "debian12",
+		"username":               "debian",
+		"password":               "N3w-Passw0rd!!",
+	})
+	if plan := testPlan(t, provider, "dutchis_virtualserver", state, config); plan.RequiresNew() {
+		t.Fatalf("changing os with reinstall_on_os_change replaces the virtual server: %v", plan)
+	}
+	state = mustApply(t, provider, state, config)
+
+	if state.ID != id || state.Attributes["ipv4_address"] != ip {
+		t.Errorf("reinstall changed the virtual server: %s %s -> %s %s", id, ip, state.ID, state.Attributes["ipv4_address"])
+	}
+	if got := handler.count(http.MethodPost, "/reinstall"); got != 1 {
+		t.Errorf("%d reinstall requests, want 1", got)
+	}
+	if got := handler.count(http.MethodPost, "/resetpassword"); got != 0 {
+		t.Errorf("%d password reset requests next to the reinstall, want 0", got)
+	}
+
+	config["os"] = "debian11"
+	delete(config, "reinstall_on_os_change")
+	if plan := testPlan(t, provider, "dutchis_virtualserver", state, config); !plan.RequiresNew() {
+		t.Fatal("changing os without reinstall_on_os_change does not replace the virtual server")
+	}
+}
+
+func TestResourceVirtualServerReinstallRecordsStateOnFailure(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+
+	config := testVirtualServerConfig(map[string]interface{}{"reinstall_on_os_change": true})
+	state := mustApply(t, provider, nil, config)
+
+	// the reinstall is accepted, but polling for its completion fails
+	handler.fail = func(r *http.Request, requests []string) int {
+		for _, request := range requests {
+			if strings.HasSuffix(request, "/reinstall") && r.Method == http.MethodGet {
+				return http.StatusInternalServerError
+			}
+		}
+		return 0
+	}
+
+	config["os"] = "debian12"
+	delete(config, "password")
+	state, diags := testApply(t, provider, "dutchis_virtualserver", state, config)
+	if !diags.HasError() {
+		t.Fatal("expected the apply to fail")
+	}
+	if got := state.Attributes["os"]; got != "debian12" {
+		t.Errorf("os after a failed wait = %q, want the reinstalled debian12", got)
+	}
+}
+
+func TestResourceVirtualServerIPLookupFailure(t *testing.T) {
+	handler := &recordingHandler{next: newTestFakeAPI()}
+	provider := newTestProvider(t, handler)
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+
+	handler.fail = func(r *http.Request, requests []string) int {
+		if strings.HasSuffix(r.URL.Path, "/ips") {
+			return http.StatusServiceUnavailable
+		}
+		return 0
+	}
+
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	data := resource.Data(state)
+	diags := resource.ReadContext(context.Background(), data, provider.Meta())
+	if diags.HasError() || len(diags) != 1 || diags[0].Severity != diag.Warning {
+		t.Fatalf("expected a single warning, got %v", diags)
+	}
+	if got := data.Get("ipv4_address").(string); got != state.Attributes["ipv4_address"] {
+		t.Errorf("ipv4_address = %q after a failed lookup, want it unchanged", got)
+	}
+}
+
+func TestResourceVirtualServerImportRetiredOS(t *testing.T) {
+	fake := newTestFakeAPI()
+	provider := newTestProvider(t, fake)
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	apiClient := provider.Meta().(*providerConfiguration).Client
+	ctx := context.Background()
+
+	id, err := apiClient.CreateVirtualServer(ctx, client.CreateVirtualServerRequest{
+		Hostname: "legacy-1", Class: "performance", Os: "ubuntu2004", Username: "admin",
+		Password: "Str0ng-Passw0rd", Cores: 2, Memory: 4, Network: 1, Disk: 50,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitForVirtualServerRunning(ctx, apiClient, id, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	// the image the server was installed with is no longer offered
+	var images []client.OSImage
+	for _, image := range fake.OSImages {
+		if image.ID != "ubuntu2004" {
+			images = append(images, image)
+		}
+	}
+	fake.OSImages = images
+
+	imported, err := resource.Importer.StateContext(ctx, resource.Data(&terraform.InstanceState{ID: id}), provider.Meta())
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if diags := resource.ReadContext(ctx, imported[0], provider.Meta()); diags.HasError() {
+		t.Fatalf("read after import failed: %v", diags)
+	}
+
+	config := testVirtualServerConfig(map[string]interface{}{"hostname": "legacy-1", "os": "ubuntu2004"})
+	state, diags := testApply(t, provider, "dutchis_virtualserver", imported[0].State(), config)
+	if diags.HasError() {
+		t.Fatalf("adopting a virtual server with a retired os failed: %v", diags)
+	}
+	if state.ID != id || state.Attributes["os"] != "ubuntu2004" {
+		t.Fatalf("retired os was not recorded: %v", state.Attributes)
+	}
+
+	config["os"] = "ubuntu2004-minimal"
+	if _, diags := testApply(t, provider, "dutchis_virtualserver", state, config); !diags.HasError() || !strings.Contains(diags[0].Summary, `os "ubuntu2004-minimal" is not available`) {
+		t.Fatalf("changing to an unknown os after adopting was not refused: %v", diags)
+	}
+}
+
+func TestResourceVirtualServerDeletedOutOfBand(t *testing.T) {
+	provider := newTestProvider(t, newTestFakeAPI())
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	apiClient := provider.Meta().(*providerConfiguration).Client
+	ctx := context.Background()
+
+	state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+	if err := apiClient.DeleteVirtualServer(ctx, state.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForVirtualServerDeleted(ctx, apiClient, state.ID, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	data := resource.Data(state)
+	diags := resource.ReadContext(ctx, data, provider.Meta())
+	if len(diags) != 1 || diags[0].Severity != diag.Warning || diags[0].Summary != "Virtual server not found" {
+		t.Fatalf("expected a single warning about the missing virtual server, got %v", diags)
+	}
+	if data.Id() != "" {
+		t.Errorf("ID = %q after reading a deleted virtual server, want it removed from state", data.Id())
+	}
+}
+
+func TestResourceVirtualServerDelete(t *testing.T) {
+	fake := newTestFakeAPI()
+	fake.DeleteDuration = 500 * time.Millisecond
+	provider := newTestProvider(t, fake)
+	resource := provider.ResourcesMap["dutchis_virtualserver"]
+	apiClient := provider.Meta().(*providerConfiguration).Client
+	ctx := context.Background()
+	destroy := func(state *terraform.InstanceState) diag.Diagnostics {
+		_, diags := resource.Apply(ctx, state, &terraform.InstanceDiff{Destroy: true}, provider.Meta())
+		return diags
+	}
+
+	t.Run("waits until gone", func(t *testing.T) {
+		state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+		if diags := destroy(state); diags.HasError() {
+			t.Fatalf("destroy failed: %v", diags)
+		}
+		// the fake API keeps showing the server for DeleteDuration after the request
+		if _, err := apiClient.GetVirtualServer(ctx, state.ID); !client.IsNotFound(err) {
+			t.Fatalf("destroy returned while the virtual server still exists: %v", err)
+		}
+	})
+
+	t.Run("already gone", func(t *testing.T) {
+		state := mustApply(t, provider, nil, testVirtualServerConfig(nil))
+		if diags := destroy(state); diags.HasError() {
+			t.Fatalf("destroy failed: %v", diags)
+		}
+		if diags := destroy(state); diags.HasError() {
+			t.Fatalf("destroying a virtual server that is already gone failed: %v", diags)
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		// the fake API refuses to delete servers that are still installing
+		fake.InstallDuration = time.Minute
+		id, err := apiClient.CreateVirtualServer(ctx, client.CreateVirtualServerRequest{
+			Hostname: "web-2", Class: "performance", Os: "ubuntu2204", Username: "admin",
+			Password: "Str0ng-Passw0rd", Cores: 2, Memory: 4, Network: 1, Disk: 50,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		diags := destroy(&terraform.InstanceState{ID: id, Attributes: map[string]string{"id": id}})
+		if !diags.HasError() || diags[0].Summary != "Failed to delete virtual server" || !strings.Contains(diags[0].Detail, "virtual server is locked") {
+			t.Fatalf("expected the refusal of the API, got %v", diags)
+		}
+		if _, err := apiClient.GetVirtualServer(ctx, id); err != nil {
+			t.Errorf("refused delete removed the virtual server: %v", err)
+		}
+	})
+}
+
+func TestResourceVirtualServerCreateTimeout(t *testing.T) {
+	fake := newTestFakeAPI()
+	fake.InstallDuration = time.Minute
+	provider := newTestProvider(t, fake)
+
+	config := testVirtualServerConfig(map[string]interface{}{
+		"timeouts": []interface{}{map[string]interface{}{"create": "300ms"}},
+	})
+	_, diags := testApply(t, provider, "dutchis_virtualserver", nil, config)
+	if !diags.HasError() {
+		t.Fatal("expected the create to time out")
+	}
+	for _, want := range []string{"last observed status", "installing: true"} {
+		if !strings.Contains(diags[0].Detail, want) {
+			t.Errorf("error %q does not contain %q", diags[0].Detail, want)
+		}
+	}
+}
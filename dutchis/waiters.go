@@ -0,0 +1,138 @@
+package dutchis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dutchis/terraform/dutchis/client"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/retry"
+)
+
+const (
+	virtualServerStatePending = "pending"
+	virtualServerStateReady   = "ready"
+	virtualServerStateDeleted = "deleted"
+)
+
+// waiterDelay and waiterMinTimeout set how soon and how often the waiters poll the API.
+// They are variables so tests against the fake API do not wait for seconds.
+var (
+	waiterDelay      = 3 * time.Second
+	waiterMinTimeout = 5 * time.Second
+)
+
+// waitForVirtualServer polls the virtual server until ready returns true for it. On failure
+// the error describes the last server the API reported, prefixed with what was expected.
+func waitForVirtualServer(ctx context.Context, c *client.Client, uuid string, timeout time.Duration, expected string, ready func(*client.VirtualServer) bool) (*client.VirtualServer, error) {
+	var mu sync.Mutex
+	var last *client.VirtualServer
+
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{virtualServerStatePending},
+		Target:  []string{virtualServerStateReady},
+		Refresh: func() (interface{}, string, error) {
+			virtualserver, err := c.GetVirtualServer(ctx, uuid)
+			if err != nil {
+				return nil, "", err
+			}
+
+			mu.Lock()
+			last = virtualserver
+			mu.Unlock()
+
+			if ready(virtualserver) {
+				return virtualserver, virtualServerStateReady, nil
+			}
+			return virtualserver, virtualServerStatePending, nil
+		},
+		Timeout:    timeout,
+		Delay:      waiterDelay,
+		MinTimeout: waiterMinTimeout,
+	}
+
+	result, err := stateConf.WaitForStateContext(ctx)
+	if err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		if last != nil {
+			return nil, fmt.Errorf("virtual server %s %s (last observed status %q, installing: %t, %d cores, %d GB memory, %d GB disk): %w",
+				uuid, expected, last.Status, last.Installing, last.Cpus, last.Maxmem, last.Maxdisk, err)
+		}
+		return nil, err
+	}
+	return result.(*client.VirtualServer), nil
+}
+
+// waitForVirtualServerStatus waits until the virtual server reports status and is no longer installing.
+func waitForVirtualServerStatus(ctx context.Context, c *client.Client, uuid string, status string, timeout time.Duration) (*client.VirtualServer, error) {
+	return waitForVirtualServer(ctx, c, uuid, timeout, fmt.Sprintf("did not become %s", status), func(virtualserver *client.VirtualServer) bool {
+		return virtualserver.Status == status && !virtualserver.Installing
+	})
+}
+
+// waitForVirtualServerRunning waits until the virtual server is running and no longer installing.
+func waitForVirtualServerRunning(ctx context.Context, c *client.Client, uuid string, timeout time.Duration) (*client.VirtualServer, error) {
+	return waitForVirtualServerStatus(ctx, c, uuid, "running", timeout)
+}
+
+// waitForVirtualServerSpecs waits until the API reports the requested specs and the
+// upgrade is over. Network speed is not reported by the API and therefore not checked.
+func waitForVirtualServerSpecs(ctx context.Context, c *client.Client, uuid string, specs client.UpdateVirtualServerSpecsRequest, timeout time.Duration) (*client.VirtualServer, error) {
+	return waitForVirtualServer(ctx, c, uuid, timeout, "did not apply the new specs", func(virtualserver *client.VirtualServer) bool {
+		applied := (specs.Cores == 0 || virtualserver.Cpus == specs.Cores) &&
+			(specs.Memory == 0 || virtualserver.Maxmem == specs.Memory) &&
+			(specs.Disk == 0 || virtualserver.Maxdisk == specs.Disk)
+		return applied && (virtualserver.Status == "running" || virtualserver.Status == "stopped")
+	})
+}
+
+// waitForVirtualServerIdle waits until the virtual server is running or stopped and no
+// longer busy with an operation like a password reset.
+func waitForVirtualServerIdle(ctx context.Context, c *client.Client, uuid string, timeout time.Duration) (*client.VirtualServer, error) {
+	return waitForVirtualServer(ctx, c, uuid, timeout, "did not finish the operation", func(virtualserver *client.VirtualServer) bool {
+		return !virtualserver.Installing && (virtualserver.Status == "running" || virtualserver.Status == "stopped")
+	})
+}
+
+// waitForVirtualServerDeleted polls the virtual server until the API no longer knows it.
+func waitForVirtualServerDeleted(ctx context.Context, c *client.Client, uuid string, timeout time.Duration) error {
+	var mu sync.Mutex
+	var last *client.VirtualServer
+
+	stateConf := &retry.StateChangeConf{
+		Pending: []string{virtualServerStatePending},
+		Target:  []string{virtualServerStateDeleted},
+		Refresh: func() (interface{}, string, error) {
+			virtualserver, err := c.GetVirtualServer(ctx, uuid)
+			if client.IsNotFound(err) {
+				// the result must be non-nil for the waiter to consider the target reached
+				return struct{}{}, virtualServerStateDeleted, nil
+			}
+			if err != nil {
+				return nil, "", err
+			}
+
+			mu.Lock()
+			last = virtualserver
+			mu.Unlock()
+
+			return virtualserver, virtualServerStatePending, nil
+		},
+		Timeout:    timeout,
+		Delay:      waiterDelay,
+		MinTimeout: waiterMinTimeout,
+	}
+
+	_, err := stateConf.WaitForStateContext(ctx)
+	if err != nil {
+		mu.Lock()
+		defer mu.Unlock()
+		if last != nil {
+			return fmt.Errorf("virtual server %s still exists (last observed status %q): %w", uuid, last.Status, err)
+		}
+		return err
+	}
+	return nil
+}
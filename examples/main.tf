@@ -12,18 +12,47 @@ provider "dutchis" {
     dutchis_api_token = "token"
 }
 
+resource "dutchis_sshkey" "deploy" {
+    name = "deploy"
+    public_key = file("~/.ssh/id_ed25519.pub")
+}
+
+data "dutchis_sshkey" "admin" {
+    name = "admin" # Or look the key up by fingerprint
+}
+
+data "dutchis_os_images" "ubuntu" {
+    family = "ubuntu"
+    latest = true
+}
+
 resource "virtualserver" "example-vs" {
     count = 3 # Amount to create
     hostname = "server-${count.index}" # Hostname of the virtual server
     class = "performance" # Performance class
-    os = "ubuntu2204" # OS
+    os = data.dutchis_os_images.ubuntu.ids[0] # OS, e.g. "ubuntu2204"
     username = "exampleuser" # Ignored on windows systems
-    password = "ferrysekur" # Default user's password
+    password = "Ferry-Sekur-2023" # Default user's password, generated when omitted
     sshkeys = [
-        "0b4d8fc1-ddea-11ed-b7cc-9a049341f472"
+        data.dutchis_sshkey.admin.uuid,
+        dutchis_sshkey.deploy.uuid
     ]
     cores = 2 # Number of cores
     memory = 4 # Memory in GB
     network = 1 # Network speed in Gbps
     disk = 50 # Disk speed in GB
+    # Cloud-init user data, plain text or base64 encoded, e.g. base64gzip(file("cloud-init.yaml"))
+    user_data = <<-EOT
+        #cloud-config
+        package_update: true
+        packages:
+          - nginx
+    EOT
+    reinstall_on_os_change = false # Reinstall in place instead of replacing the server when os changes
+    allow_disk_shrink_replace = false # Replace the server when disk is lowered, destroying its data
+    power_state = "running" # Either running or stopped
+}
+
+data "dutchis_virtualserver" "existing" {
+    hostname = "legacy-server" # Or look the server up by uuid
 }